@@ -7,6 +7,8 @@ require (
 	github.com/spf13/viper v1.20.1
 	go.uber.org/fx v1.20.0
 	go.uber.org/zap v1.26.0
+	golang.org/x/crypto v0.32.0
+	golang.org/x/text v0.21.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -24,5 +26,4 @@ require (
 	go.uber.org/dig v1.17.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"runtime/debug"
 	"strings"
 
@@ -21,19 +22,28 @@ type Config struct {
 	Auth           Auth           `yaml:"auth" mapstructure:"auth"`
 	Logging        Logging        `yaml:"logging" mapstructure:"logging"`
 	EndpointConfig EndpointConfig `yaml:"endpoint_config" mapstructure:"endpoint_config"`
+	Prompts        []Prompt       `yaml:"prompts,omitempty" mapstructure:"prompts"`
+	Completion     Completion     `yaml:"completion" mapstructure:"completion"`
+	Tools          Tools          `yaml:"tools" mapstructure:"tools"`
+	Uploads        Uploads        `yaml:"uploads" mapstructure:"uploads"`
+	Responses      Responses      `yaml:"responses" mapstructure:"responses"`
 }
 
 // Server configuration for MCP server
 type Server struct {
-	Mode string `yaml:"mode" mapstructure:"mode"`
-	Host string `yaml:"host" mapstructure:"host"`
-	Port int    `yaml:"port" mapstructure:"port"`
+	Mode           string `yaml:"mode" mapstructure:"mode"`
+	Host           string `yaml:"host" mapstructure:"host"`
+	Port           int    `yaml:"port" mapstructure:"port"`
+	MaxConcurrency int    `yaml:"max_concurrency" mapstructure:"max_concurrency"`
+	PageSize       int    `yaml:"page_size" mapstructure:"page_size"`
 }
 
 // Upstream configuration for the target API
 type Upstream struct {
-	BaseURL string `yaml:"base_url" mapstructure:"base_url"`
-	Timeout int    `yaml:"timeout" mapstructure:"timeout"`
+	BaseURL         string `yaml:"base_url" mapstructure:"base_url"`
+	Timeout         int    `yaml:"timeout" mapstructure:"timeout"`
+	PollInterval    int    `yaml:"poll_interval" mapstructure:"poll_interval"`
+	PollMaxAttempts int    `yaml:"poll_max_attempts" mapstructure:"poll_max_attempts"`
 }
 
 // Auth configuration for authentication
@@ -43,6 +53,38 @@ type Auth struct {
 	Username string `yaml:"username" mapstructure:"username"`
 	Password string `yaml:"password" mapstructure:"password"`
 	APIKey   string `yaml:"api_key" mapstructure:"api_key"`
+
+	// OAuth2 client credentials and refresh token grants. TokenURL defaults
+	// to the tokenUrl of the spec's oauth2 security scheme.
+	ClientID     string   `yaml:"client_id" mapstructure:"client_id"`
+	ClientSecret string   `yaml:"client_secret" mapstructure:"client_secret"`
+	TokenURL     string   `yaml:"token_url" mapstructure:"token_url"`
+	Scopes       []string `yaml:"scopes,omitempty" mapstructure:"scopes"`
+	RefreshToken string   `yaml:"refresh_token" mapstructure:"refresh_token"`
+
+	// Authorization code flow used by the login command. AuthorizationURL
+	// defaults to the spec's authorizationUrl and RedirectPort 0 picks a
+	// free port. Tokens are kept in TokenStore, encrypted with a key derived
+	// from TokenStoreKey or kept in a key file next to the store.
+	AuthorizationURL string `yaml:"authorization_url" mapstructure:"authorization_url"`
+	RedirectPort     int    `yaml:"redirect_port" mapstructure:"redirect_port"`
+	TokenStore       string `yaml:"token_store" mapstructure:"token_store"`
+	TokenStoreKey    string `yaml:"token_store_key" mapstructure:"token_store_key"`
+
+	// Credentials maps security scheme names of the spec to credentials.
+	// Requests then authenticate with the schemes their operation requires.
+	Credentials map[string]Credential `yaml:"credentials,omitempty" mapstructure:"credentials"`
+}
+
+// Credential authenticates for one security scheme of the spec: an API key
+// for apiKey schemes, a token for http bearer, oauth2 and openIdConnect
+// schemes, or a username and password for http basic schemes. oauth2 schemes
+// without a token use the oauth2 settings of Auth.
+type Credential struct {
+	Token    string `yaml:"token,omitempty" mapstructure:"token"`
+	Username string `yaml:"username,omitempty" mapstructure:"username"`
+	Password string `yaml:"password,omitempty" mapstructure:"password"`
+	APIKey   string `yaml:"api_key,omitempty" mapstructure:"api_key"`
 }
 
 // Logging configuration
@@ -58,6 +100,114 @@ type EndpointConfig struct {
 	Endpoints      map[string]string `yaml:"endpoints" mapstructure:"endpoints"`
 }
 
+// Prompt is a user-defined MCP prompt template
+type Prompt struct {
+	Name        string           `yaml:"name" mapstructure:"name"`
+	Description string           `yaml:"description" mapstructure:"description"`
+	Arguments   []PromptArgument `yaml:"arguments" mapstructure:"arguments"`
+	Operations  []string         `yaml:"operations" mapstructure:"operations"`
+	Template    string           `yaml:"template" mapstructure:"template"`
+}
+
+// PromptArgument describes an argument of a user-defined prompt
+type PromptArgument struct {
+	Name        string `yaml:"name" mapstructure:"name"`
+	Description string `yaml:"description" mapstructure:"description"`
+	Required    bool   `yaml:"required" mapstructure:"required"`
+}
+
+// Completion configures argument completion
+type Completion struct {
+	Lookups []CompletionLookup `yaml:"lookups,omitempty" mapstructure:"lookups"`
+}
+
+// CompletionLookup completes an argument with live values returned by an operation
+type CompletionLookup struct {
+	Argument  string                 `yaml:"argument" mapstructure:"argument"`
+	Operation string                 `yaml:"operation" mapstructure:"operation"`
+	Values    string                 `yaml:"values" mapstructure:"values"`
+	Arguments map[string]interface{} `yaml:"arguments,omitempty" mapstructure:"arguments"`
+	Tools     []string               `yaml:"tools,omitempty" mapstructure:"tools"`
+	CacheTTL  int                    `yaml:"cache_ttl" mapstructure:"cache_ttl"`
+}
+
+// Uploads configures where file parts of request bodies may be read from
+type Uploads struct {
+	AllowedDirs []string `yaml:"allowed_dirs" mapstructure:"allowed_dirs"`
+}
+
+// Responses configures how upstream responses are rendered for the model
+// and how much of them is returned. Larger responses are truncated and kept
+// as in-memory resources.
+type Responses struct {
+	Format       string              `yaml:"format" mapstructure:"format"`
+	Headers      []string            `yaml:"headers,omitempty" mapstructure:"headers"`
+	MaxBytes     int64               `yaml:"max_bytes" mapstructure:"max_bytes"`
+	MaxTokens    int                 `yaml:"max_tokens" mapstructure:"max_tokens"`
+	MaxReadBytes int64               `yaml:"max_read_bytes" mapstructure:"max_read_bytes"`
+	StoreBytes   int64               `yaml:"store_bytes" mapstructure:"store_bytes"`
+	StoreTTL     int                 `yaml:"store_ttl" mapstructure:"store_ttl"`
+	Operations   []OperationResponse `yaml:"operations,omitempty" mapstructure:"operations"`
+}
+
+// OperationResponse overrides the response rendering and budget of one
+// operation. Template is a Go text/template replacing the rendered result.
+type OperationResponse struct {
+	Operation string   `yaml:"operation" mapstructure:"operation"`
+	Format    string   `yaml:"format,omitempty" mapstructure:"format"`
+	Headers   []string `yaml:"headers,omitempty" mapstructure:"headers"`
+	Template  string   `yaml:"template,omitempty" mapstructure:"template"`
+	MaxBytes  int64    `yaml:"max_bytes,omitempty" mapstructure:"max_bytes"`
+	MaxTokens int      `yaml:"max_tokens,omitempty" mapstructure:"max_tokens"`
+}
+
+// Tools configures how operations are exposed as tools
+type Tools struct {
+	Mode            string            `yaml:"mode" mapstructure:"mode"`
+	ReadOnly        bool              `yaml:"read_only" mapstructure:"read_only"`
+	CoerceArguments bool              `yaml:"coerce_arguments" mapstructure:"coerce_arguments"`
+	Include         ToolFilter        `yaml:"include,omitempty" mapstructure:"include"`
+	Exclude         ToolFilter        `yaml:"exclude,omitempty" mapstructure:"exclude"`
+	Annotations     []ToolAnnotations `yaml:"annotations,omitempty" mapstructure:"annotations"`
+}
+
+// ToolFilter selects operations. Each list matches if any of its entries
+// matches; empty lists are ignored.
+type ToolFilter struct {
+	Tags         []string `yaml:"tags,omitempty" mapstructure:"tags"`
+	Methods      []string `yaml:"methods,omitempty" mapstructure:"methods"`
+	Paths        []string `yaml:"paths,omitempty" mapstructure:"paths"`
+	OperationIDs []string `yaml:"operation_ids,omitempty" mapstructure:"operation_ids"`
+	Scopes       []string `yaml:"scopes,omitempty" mapstructure:"scopes"`
+}
+
+// ToolAnnotations overrides the annotations derived for one operation
+type ToolAnnotations struct {
+	Operation       string `yaml:"operation" mapstructure:"operation"`
+	Title           string `yaml:"title,omitempty" mapstructure:"title"`
+	ReadOnlyHint    *bool  `yaml:"read_only_hint,omitempty" mapstructure:"read_only_hint"`
+	DestructiveHint *bool  `yaml:"destructive_hint,omitempty" mapstructure:"destructive_hint"`
+	IdempotentHint  *bool  `yaml:"idempotent_hint,omitempty" mapstructure:"idempotent_hint"`
+	OpenWorldHint   *bool  `yaml:"open_world_hint,omitempty" mapstructure:"open_world_hint"`
+}
+
+// Response format constants
+const (
+	ResponseFormatJSON        = "json"
+	ResponseFormatCompactJSON = "compact"
+	ResponseFormatYAML        = "yaml"
+	ResponseFormatMarkdown    = "markdown"
+)
+
+// HeadersNone in a headers allowlist omits all response headers
+const HeadersNone = "none"
+
+// Tools mode constants
+const (
+	ToolsModeOperations = "operations"
+	ToolsModeDiscovery  = "discovery"
+)
+
 // Server mode constants
 const (
 	ServerModeSTDIO = "stdio"
@@ -72,13 +222,37 @@ func InitFlags() {
 	pflag.String("mode", "stdio", "Server mode (stdio, http, sse)")
 	pflag.String("host", "localhost", "Server host")
 	pflag.Int("port", 8080, "Server port")
+	pflag.Int("max-concurrency", 8, "Maximum number of requests processed concurrently in stdio mode")
+	pflag.Int("page-size", 100, "Number of tools per tools/list page (0 disables pagination)")
 	pflag.String("upstream-base-url", "", "Upstream API base URL")
 	pflag.Int("upstream-timeout", 30, "Upstream API timeout in seconds")
+	pflag.Int("upstream-poll-interval", 2, "Default interval in seconds between polls of asynchronous (202) operations")
+	pflag.Int("upstream-poll-max-attempts", 0, "Maximum polls of asynchronous (202) operations (0 disables polling)")
+	pflag.String("tools-mode", "operations", "Tool exposure mode (operations, discovery)")
+	pflag.Bool("read-only", false, "Expose only GET and HEAD operations as tools")
+	pflag.Bool("coerce-arguments", false, "Accept numeric strings and \"true\"/\"false\" for number and boolean arguments, and numbers and booleans for string arguments")
+	pflag.StringSlice("upload-allowed-dirs", nil, "Directories from which request body files may be read by path")
+	pflag.String("response-format", "json", "Tool result format (json, compact, yaml, markdown)")
+	pflag.StringSlice("response-headers", nil, "Response headers included in tool results (default all, \"none\" for none)")
+	pflag.Int64("response-max-bytes", 100000, "Largest tool result in bytes before the response is truncated (0 disables the limit)")
+	pflag.Int("response-max-tokens", 25000, "Largest tool result in estimated tokens before the response is truncated (0 disables the limit)")
+	pflag.Int64("response-max-read-bytes", 64<<20, "Largest upstream response body read in bytes (0 disables the limit)")
+	pflag.Int64("response-store-bytes", 256<<20, "Memory in bytes for full responses kept as resources")
+	pflag.Int("response-store-ttl", 3600, "Seconds a full response is kept as a resource")
 	pflag.String("auth-type", "none", "Authentication type (none, bearer, basic, apikey, oauth2)")
 	pflag.String("auth-token", "", "Authentication token")
 	pflag.String("auth-username", "", "Authentication username")
 	pflag.String("auth-password", "", "Authentication password")
 	pflag.String("auth-api-key", "", "Authentication API key")
+	pflag.String("auth-client-id", "", "OAuth2 client ID")
+	pflag.String("auth-client-secret", "", "OAuth2 client secret")
+	pflag.String("auth-token-url", "", "OAuth2 token URL (defaults to the spec's tokenUrl)")
+	pflag.StringSlice("auth-scopes", nil, "OAuth2 scopes to request")
+	pflag.String("auth-refresh-token", "", "OAuth2 refresh token")
+	pflag.String("auth-authorization-url", "", "OAuth2 authorization URL for login (defaults to the spec's authorizationUrl)")
+	pflag.Int("auth-redirect-port", 0, "Loopback port for the login redirect (0 picks a free port)")
+	pflag.String("auth-token-store", "", "Encrypted OAuth2 token store (defaults to oas-mcp/tokens.json in the user config directory)")
+	pflag.String("auth-token-store-key", "", "Passphrase for the token store (defaults to a generated key file)")
 	pflag.String("log-level", "info", "Log level")
 	pflag.Bool("log-disable-console", false, "Disable console logging")
 	pflag.String("log-file", "", "Log file path")
@@ -88,13 +262,37 @@ func InitFlags() {
 	viper.BindPFlag("server.mode", pflag.Lookup("mode"))
 	viper.BindPFlag("server.host", pflag.Lookup("host"))
 	viper.BindPFlag("server.port", pflag.Lookup("port"))
+	viper.BindPFlag("server.max_concurrency", pflag.Lookup("max-concurrency"))
+	viper.BindPFlag("server.page_size", pflag.Lookup("page-size"))
 	viper.BindPFlag("upstream.base_url", pflag.Lookup("upstream-base-url"))
 	viper.BindPFlag("upstream.timeout", pflag.Lookup("upstream-timeout"))
+	viper.BindPFlag("upstream.poll_interval", pflag.Lookup("upstream-poll-interval"))
+	viper.BindPFlag("upstream.poll_max_attempts", pflag.Lookup("upstream-poll-max-attempts"))
+	viper.BindPFlag("tools.mode", pflag.Lookup("tools-mode"))
+	viper.BindPFlag("tools.read_only", pflag.Lookup("read-only"))
+	viper.BindPFlag("tools.coerce_arguments", pflag.Lookup("coerce-arguments"))
+	viper.BindPFlag("uploads.allowed_dirs", pflag.Lookup("upload-allowed-dirs"))
+	viper.BindPFlag("responses.format", pflag.Lookup("response-format"))
+	viper.BindPFlag("responses.headers", pflag.Lookup("response-headers"))
+	viper.BindPFlag("responses.max_bytes", pflag.Lookup("response-max-bytes"))
+	viper.BindPFlag("responses.max_tokens", pflag.Lookup("response-max-tokens"))
+	viper.BindPFlag("responses.max_read_bytes", pflag.Lookup("response-max-read-bytes"))
+	viper.BindPFlag("responses.store_bytes", pflag.Lookup("response-store-bytes"))
+	viper.BindPFlag("responses.store_ttl", pflag.Lookup("response-store-ttl"))
 	viper.BindPFlag("auth.type", pflag.Lookup("auth-type"))
 	viper.BindPFlag("auth.token", pflag.Lookup("auth-token"))
 	viper.BindPFlag("auth.username", pflag.Lookup("auth-username"))
 	viper.BindPFlag("auth.password", pflag.Lookup("auth-password"))
 	viper.BindPFlag("auth.api_key", pflag.Lookup("auth-api-key"))
+	viper.BindPFlag("auth.client_id", pflag.Lookup("auth-client-id"))
+	viper.BindPFlag("auth.client_secret", pflag.Lookup("auth-client-secret"))
+	viper.BindPFlag("auth.token_url", pflag.Lookup("auth-token-url"))
+	viper.BindPFlag("auth.scopes", pflag.Lookup("auth-scopes"))
+	viper.BindPFlag("auth.refresh_token", pflag.Lookup("auth-refresh-token"))
+	viper.BindPFlag("auth.authorization_url", pflag.Lookup("auth-authorization-url"))
+	viper.BindPFlag("auth.redirect_port", pflag.Lookup("auth-redirect-port"))
+	viper.BindPFlag("auth.token_store", pflag.Lookup("auth-token-store"))
+	viper.BindPFlag("auth.token_store_key", pflag.Lookup("auth-token-store-key"))
 	viper.BindPFlag("logging.level", pflag.Lookup("log-level"))
 	viper.BindPFlag("logging.disable_console", pflag.Lookup("log-disable-console"))
 	viper.BindPFlag("logging.file", pflag.Lookup("log-file"))
@@ -176,6 +374,19 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server mode: %s (must be stdio, http, or sse)", c.Server.Mode)
 	}
 
+	if c.Server.MaxConcurrency < 1 {
+		return fmt.Errorf("server max_concurrency must be at least 1")
+	}
+
+	if c.Server.PageSize < 0 {
+		return fmt.Errorf("server page_size must not be negative")
+	}
+
+	// Validate asynchronous polling
+	if c.Upstream.PollInterval < 0 || c.Upstream.PollMaxAttempts < 0 {
+		return fmt.Errorf("upstream poll_interval and poll_max_attempts must not be negative")
+	}
+
 	// Validate auth type
 	validAuthTypes := map[string]bool{
 		"none":   true,
@@ -189,6 +400,80 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid auth type: %s", c.Auth.Type)
 	}
 
+	// Validate prompt templates
+	promptNames := make(map[string]bool)
+	for _, prompt := range c.Prompts {
+		if prompt.Name == "" {
+			return fmt.Errorf("prompt name is required")
+		}
+		if promptNames[prompt.Name] {
+			return fmt.Errorf("duplicate prompt name: %s", prompt.Name)
+		}
+		promptNames[prompt.Name] = true
+
+		if prompt.Template == "" {
+			return fmt.Errorf("prompt %s: template is required", prompt.Name)
+		}
+	}
+
+	// Validate completion lookups
+	for _, lookup := range c.Completion.Lookups {
+		if lookup.Argument == "" || lookup.Operation == "" || lookup.Values == "" {
+			return fmt.Errorf("completion lookup requires argument, operation and values")
+		}
+		if lookup.CacheTTL < 0 {
+			return fmt.Errorf("completion lookup %s: cache_ttl must not be negative", lookup.Argument)
+		}
+	}
+
+	// Validate tools mode
+	if c.Tools.Mode != ToolsModeOperations && c.Tools.Mode != ToolsModeDiscovery {
+		return fmt.Errorf("invalid tools mode: %s (must be operations or discovery)", c.Tools.Mode)
+	}
+
+	// Validate tool filters
+	for _, filter := range []ToolFilter{c.Tools.Include, c.Tools.Exclude} {
+		if err := filter.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate tool annotation overrides
+	for _, annotations := range c.Tools.Annotations {
+		if annotations.Operation == "" {
+			return fmt.Errorf("tool annotations require an operation")
+		}
+	}
+
+	// Validate upload directories
+	for _, dir := range c.Uploads.AllowedDirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("upload directory does not exist: %s", dir)
+		}
+	}
+
+	// Validate response rendering and budgets
+	if !validResponseFormat(c.Responses.Format) {
+		return fmt.Errorf("invalid responses format: %s (must be json, compact, yaml or markdown)", c.Responses.Format)
+	}
+	if c.Responses.MaxBytes < 0 || c.Responses.MaxTokens < 0 || c.Responses.MaxReadBytes < 0 {
+		return fmt.Errorf("responses max_bytes, max_tokens and max_read_bytes must not be negative")
+	}
+	if c.Responses.StoreBytes < 0 || c.Responses.StoreTTL < 1 {
+		return fmt.Errorf("responses store_bytes must not be negative and store_ttl must be at least 1")
+	}
+	for _, response := range c.Responses.Operations {
+		if response.Operation == "" {
+			return fmt.Errorf("response settings require an operation")
+		}
+		if response.Format != "" && !validResponseFormat(response.Format) {
+			return fmt.Errorf("response settings %s: invalid format: %s", response.Operation, response.Format)
+		}
+		if response.MaxBytes < 0 || response.MaxTokens < 0 {
+			return fmt.Errorf("response settings %s: max_bytes and max_tokens must not be negative", response.Operation)
+		}
+	}
+
 	// Validate auth configuration based on type
 	switch c.Auth.Type {
 	case "bearer", "apikey":
@@ -199,6 +484,62 @@ func (c *Config) Validate() error {
 		if c.Auth.Username == "" || c.Auth.Password == "" {
 			return fmt.Errorf("username and password are required for basic auth")
 		}
+	case "oauth2":
+		if c.Auth.ClientID == "" && c.Auth.RefreshToken == "" && c.Auth.Token == "" {
+			return fmt.Errorf("client_id, refresh_token or token is required for oauth2 auth")
+		}
+		if c.Auth.TokenURL != "" {
+			if u, err := url.Parse(c.Auth.TokenURL); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("invalid oauth2 token_url: %s", c.Auth.TokenURL)
+			}
+		}
+		if c.Auth.AuthorizationURL != "" {
+			if u, err := url.Parse(c.Auth.AuthorizationURL); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("invalid oauth2 authorization_url: %s", c.Auth.AuthorizationURL)
+			}
+		}
+		if c.Auth.RedirectPort < 0 || c.Auth.RedirectPort > 65535 {
+			return fmt.Errorf("invalid oauth2 redirect_port: %d", c.Auth.RedirectPort)
+		}
+	}
+
+	for name, credential := range c.Auth.Credentials {
+		if credential == (Credential{}) {
+			return fmt.Errorf("credentials for security scheme %s are empty", name)
+		}
+		if credential.Password != "" && credential.Username == "" {
+			return fmt.Errorf("credentials for security scheme %s: password requires a username", name)
+		}
+	}
+
+	return nil
+}
+
+// validResponseFormat reports whether a response format is supported
+func validResponseFormat(format string) bool {
+	switch format {
+	case ResponseFormatJSON, ResponseFormatCompactJSON, ResponseFormatYAML, ResponseFormatMarkdown:
+		return true
+	}
+	return false
+}
+
+// validate checks the methods and operationId patterns of a filter
+func (f ToolFilter) validate() error {
+	validMethods := map[string]bool{
+		"GET": true, "HEAD": true, "POST": true, "PUT": true,
+		"PATCH": true, "DELETE": true, "OPTIONS": true, "TRACE": true,
+	}
+	for _, method := range f.Methods {
+		if !validMethods[strings.ToUpper(method)] {
+			return fmt.Errorf("invalid tool filter method: %s", method)
+		}
+	}
+
+	for _, pattern := range f.OperationIDs {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid tool filter operation_ids pattern %q: %w", pattern, err)
+		}
 	}
 
 	return nil
@@ -238,13 +579,17 @@ func SaveExample(filename string) error {
 	cfg := &Config{
 		SwaggerFile: "swagger.json",
 		Server: Server{
-			Mode: "stdio",
-			Host: "localhost",
-			Port: 8080,
+			Mode:           "stdio",
+			Host:           "localhost",
+			Port:           8080,
+			MaxConcurrency: 8,
+			PageSize:       100,
 		},
 		Upstream: Upstream{
-			BaseURL: "https://api.example.com",
-			Timeout: 30,
+			BaseURL:         "https://api.example.com",
+			Timeout:         30,
+			PollInterval:    2,
+			PollMaxAttempts: 0,
 		},
 		Auth: Auth{
 			Type:     "none",
@@ -262,6 +607,23 @@ func SaveExample(filename string) error {
 			DefaultTimeout: 30,
 			Endpoints:      make(map[string]string),
 		},
+		Tools: Tools{
+			Mode:            "operations",
+			ReadOnly:        false,
+			CoerceArguments: false,
+		},
+		Uploads: Uploads{
+			AllowedDirs: []string{},
+		},
+		Responses: Responses{
+			Format:       "json",
+			Headers:      []string{},
+			MaxBytes:     100000,
+			MaxTokens:    25000,
+			MaxReadBytes: 64 << 20,
+			StoreBytes:   256 << 20,
+			StoreTTL:     3600,
+		},
 	}
 
 	data, err := yaml.Marshal(cfg)
@@ -282,13 +644,37 @@ func setDefaults() {
 	viper.SetDefault("server.mode", "stdio")
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.max_concurrency", 8)
+	viper.SetDefault("server.page_size", 100)
 	viper.SetDefault("upstream.base_url", "")
 	viper.SetDefault("upstream.timeout", 30)
+	viper.SetDefault("upstream.poll_interval", 2)
+	viper.SetDefault("upstream.poll_max_attempts", 0)
+	viper.SetDefault("tools.mode", "operations")
+	viper.SetDefault("tools.read_only", false)
+	viper.SetDefault("tools.coerce_arguments", false)
+	viper.SetDefault("uploads.allowed_dirs", []string{})
+	viper.SetDefault("responses.format", "json")
+	viper.SetDefault("responses.headers", []string{})
+	viper.SetDefault("responses.max_bytes", 100000)
+	viper.SetDefault("responses.max_tokens", 25000)
+	viper.SetDefault("responses.max_read_bytes", 64<<20)
+	viper.SetDefault("responses.store_bytes", 256<<20)
+	viper.SetDefault("responses.store_ttl", 3600)
 	viper.SetDefault("auth.type", "none")
 	viper.SetDefault("auth.token", "")
 	viper.SetDefault("auth.username", "")
 	viper.SetDefault("auth.password", "")
 	viper.SetDefault("auth.api_key", "")
+	viper.SetDefault("auth.client_id", "")
+	viper.SetDefault("auth.client_secret", "")
+	viper.SetDefault("auth.token_url", "")
+	viper.SetDefault("auth.scopes", []string{})
+	viper.SetDefault("auth.refresh_token", "")
+	viper.SetDefault("auth.authorization_url", "")
+	viper.SetDefault("auth.redirect_port", 0)
+	viper.SetDefault("auth.token_store", "")
+	viper.SetDefault("auth.token_store_key", "")
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.disable_console", false)
 	viper.SetDefault("logging.file", "")
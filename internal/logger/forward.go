@@ -0,0 +1,318 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Record is a log entry delivered to subscribers
+type Record struct {
+	Time    time.Time
+	Level   zapcore.Level
+	Message string
+	Fields  map[string]interface{}
+
+	// Session is the client session the record was logged for, or empty for
+	// records about the server itself
+	Session string
+}
+
+// SessionField is the field that ties a record to a client session
+const SessionField = "mcp_session"
+
+// sessionKey is the context key of the session id
+type sessionKey struct{}
+
+// WithSession returns a context for work done on behalf of a client session
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// Session returns a field tying a record to the session of ctx, or a field
+// that adds nothing if ctx belongs to no session
+func Session(ctx context.Context) zap.Field {
+	if id, ok := ctx.Value(sessionKey{}).(string); ok {
+		return SessionID(id)
+	}
+	return zap.Skip()
+}
+
+// SessionID returns a field tying a record to a session
+func SessionID(id string) zap.Field {
+	return zap.String(SessionField, id)
+}
+
+// recordBuffer bounds the records waiting for delivery; records beyond it are dropped
+const recordBuffer = 256
+
+// redacted replaces the values of sensitive fields
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against field, header and query names
+var sensitiveKeys = []string{
+	"authorization",
+	"password",
+	"secret",
+	"token",
+	"api_key",
+	"apikey",
+	"api-key",
+	"cookie",
+	"credential",
+}
+
+// subscriber receives the records at or above its level
+type subscriber struct {
+	level zapcore.Level
+	fn    func(Record)
+}
+
+var (
+	subscribersMu sync.RWMutex
+	subscribers   = make(map[int]*subscriber)
+	nextID        int
+	records       chan Record
+	startDispatch sync.Once
+
+	// forwardLevel is the lowest level any subscriber wants, independent of
+	// the level of the console and file output
+	forwardLevel = zap.NewAtomicLevelAt(zapcore.FatalLevel)
+)
+
+// Subscription delivers records to a subscriber until it is closed
+type Subscription struct {
+	id int
+}
+
+// Subscribe registers fn to receive every record at or above level.
+// Records are delivered asynchronously on a single goroutine with sensitive
+// fields redacted.
+func Subscribe(level zapcore.Level, fn func(Record)) *Subscription {
+	startDispatch.Do(func() {
+		records = make(chan Record, recordBuffer)
+		go dispatch()
+	})
+
+	subscribersMu.Lock()
+	id := nextID
+	nextID++
+	subscribers[id] = &subscriber{level: level, fn: fn}
+	updateForwardLevel()
+	subscribersMu.Unlock()
+
+	return &Subscription{id: id}
+}
+
+// SetLevel changes the lowest level delivered to the subscriber
+func (s *Subscription) SetLevel(level zapcore.Level) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if sub, ok := subscribers[s.id]; ok {
+		sub.level = level
+		updateForwardLevel()
+	}
+}
+
+// Close removes the subscription
+func (s *Subscription) Close() {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	delete(subscribers, s.id)
+	updateForwardLevel()
+}
+
+// updateForwardLevel recomputes forwardLevel. The caller must hold subscribersMu.
+func updateForwardLevel() {
+	lowest := zapcore.FatalLevel
+	for _, sub := range subscribers {
+		if sub.level < lowest {
+			lowest = sub.level
+		}
+	}
+	forwardLevel.SetLevel(lowest)
+}
+
+// hasSubscribers reports whether anyone is listening for records
+func hasSubscribers() bool {
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+	return len(subscribers) > 0
+}
+
+// dispatch delivers queued records to the subscribers
+func dispatch() {
+	for record := range records {
+		subscribersMu.RLock()
+		fns := make([]func(Record), 0, len(subscribers))
+		for _, sub := range subscribers {
+			if record.Level >= sub.level {
+				fns = append(fns, sub.fn)
+			}
+		}
+		subscribersMu.RUnlock()
+
+		for _, fn := range fns {
+			fn(record)
+		}
+	}
+}
+
+// forwardCore is a zapcore.Core that queues records for subscribers
+type forwardCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+// With implements zapcore.Core
+func (c *forwardCore) With(fields []zapcore.Field) zapcore.Core {
+	return &forwardCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check implements zapcore.Core
+func (c *forwardCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) && hasSubscribers() {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+func (c *forwardCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	session, _ := encoder.Fields[SessionField].(string)
+	delete(encoder.Fields, SessionField)
+
+	record := Record{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  redactFields(encoder.Fields),
+		Session: session,
+	}
+
+	// Never block the caller; drop records when subscribers fall behind
+	select {
+	case records <- record:
+	default:
+	}
+
+	return nil
+}
+
+// Sync implements zapcore.Core
+func (c *forwardCore) Sync() error {
+	return nil
+}
+
+// isSensitive reports whether a field, header or parameter name holds a secret
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range sensitiveKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFields returns a copy of fields with sensitive values replaced
+func redactFields(fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		result[key] = redactValue(key, value)
+	}
+	return result
+}
+
+// redactValue redacts a value stored under key, descending into maps and slices
+func redactValue(key string, value interface{}) interface{} {
+	if isSensitive(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactFields(v)
+	case map[string]string:
+		result := make(map[string]string, len(v))
+		for k, val := range v {
+			if isSensitive(k) {
+				val = redacted
+			}
+			result[k] = val
+		}
+		return result
+	case http.Header:
+		return http.Header(redactMultiMap(v))
+	case url.Values:
+		return url.Values(redactMultiMap(v))
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactValue("", item)
+		}
+		return result
+	case string:
+		if strings.EqualFold(key, "url") {
+			return redactURL(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// redactMultiMap redacts the values of sensitive keys in headers or query values
+func redactMultiMap(values map[string][]string) map[string][]string {
+	result := make(map[string][]string, len(values))
+	for key, vals := range values {
+		if isSensitive(key) {
+			vals = []string{redacted}
+		}
+		result[key] = vals
+	}
+	return result
+}
+
+// redactURL hides sensitive query parameters and user info in a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if isSensitive(name) {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
@@ -10,6 +10,9 @@ import (
 
 var globalLogger *zap.Logger
 
+// level is the configured level of the console and file output
+var level = zap.NewAtomicLevel()
+
 // LoggingConfig represents logging configuration
 type LoggingConfig struct {
 	Level          string `yaml:"level" mapstructure:"level"`
@@ -20,10 +23,11 @@ type LoggingConfig struct {
 // InitLogger initializes the global logger
 func InitLogger(cfg *LoggingConfig) error {
 	// Parse log level
-	level, err := zapcore.ParseLevel(cfg.Level)
+	parsedLevel, err := zapcore.ParseLevel(cfg.Level)
 	if err != nil {
 		return fmt.Errorf("invalid log level: %w", err)
 	}
+	level.SetLevel(parsedLevel)
 
 	// Create encoder config
 	encoderConfig := zap.NewProductionEncoderConfig()
@@ -49,11 +53,9 @@ func InitLogger(cfg *LoggingConfig) error {
 		cores = append(cores, zapcore.NewCore(fileEncoder, zapcore.AddSync(file), level))
 	}
 
-	// If no cores were created, add a default console core
-	if len(cores) == 0 {
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-		cores = append(cores, zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level))
-	}
+	// Records are offered to subscribers such as connected MCP clients at
+	// the levels they asked for
+	cores = append(cores, &forwardCore{LevelEnabler: forwardLevel})
 
 	// Create logger
 	core := zapcore.NewTee(cores...)
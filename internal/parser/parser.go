@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -39,6 +40,18 @@ type OpenAPISpec struct {
 	Components *Components           `json:"components,omitempty" yaml:"components,omitempty"`
 	Security   []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
 	Tags       []Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Consumes lists the default Swagger 2.0 request media types
+	Consumes []string `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+
+	// Definitions holds Swagger 2.0 schema definitions
+	Definitions map[string]*Schema `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+
+	// SecurityDefinitions holds Swagger 2.0 security schemes
+	SecurityDefinitions map[string]SecurityScheme `json:"securityDefinitions,omitempty" yaml:"securityDefinitions,omitempty"`
+
+	// Raw holds the document exactly as it was loaded
+	Raw []byte `json:"-" yaml:"-"`
 }
 
 // Info represents the info section of an OpenAPI spec
@@ -84,6 +97,58 @@ type Operation struct {
 	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
 	Responses   map[string]Response   `json:"responses" yaml:"responses"`
 	Security    []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+
+	// Consumes lists the Swagger 2.0 request media types
+	Consumes []string `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+
+	// Extensions holds the x-* specification extensions of the operation
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
+}
+
+// UnmarshalJSON decodes an operation and collects its extensions
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	if err := json.Unmarshal(data, (*operation)(o)); err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	o.Extensions = extensions(raw)
+
+	return nil
+}
+
+// UnmarshalYAML decodes an operation and collects its extensions
+func (o *Operation) UnmarshalYAML(value *yaml.Node) error {
+	type operation Operation
+	if err := value.Decode((*operation)(o)); err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	o.Extensions = extensions(raw)
+
+	return nil
+}
+
+// extensions returns the x-* entries of a decoded object
+func extensions(raw map[string]interface{}) map[string]interface{} {
+	var result map[string]interface{}
+	for key, value := range raw {
+		if strings.HasPrefix(key, "x-") {
+			if result == nil {
+				result = make(map[string]interface{})
+			}
+			result[key] = value
+		}
+	}
+	return result
 }
 
 // Parameter represents a parameter in the OpenAPI spec
@@ -94,6 +159,92 @@ type Parameter struct {
 	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
 	Schema      *Schema     `json:"schema,omitempty" yaml:"schema,omitempty"`
 	Example     interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	Style       string      `json:"style,omitempty" yaml:"style,omitempty"`
+	Explode     *bool       `json:"explode,omitempty" yaml:"explode,omitempty"`
+
+	// Swagger 2.0 declares the type of non-body parameters inline
+	Type    string        `json:"type,omitempty" yaml:"type,omitempty"`
+	Format  string        `json:"format,omitempty" yaml:"format,omitempty"`
+	Items   *Schema       `json:"items,omitempty" yaml:"items,omitempty"`
+	Enum    []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// CollectionFormat is the Swagger 2.0 serialization of array parameters
+	CollectionFormat string `json:"collectionFormat,omitempty" yaml:"collectionFormat,omitempty"`
+}
+
+// EffectiveSchema returns the parameter schema, synthesizing one from the
+// inline Swagger 2.0 fields when no schema is declared
+func (p *Parameter) EffectiveSchema() *Schema {
+	if p.Schema != nil {
+		return p.Schema
+	}
+	if p.Type == "" {
+		return nil
+	}
+	return &Schema{
+		Type:    p.Type,
+		Format:  p.Format,
+		Items:   p.Items,
+		Enum:    p.Enum,
+		Default: p.Default,
+	}
+}
+
+// Parameter serialization styles
+const (
+	StyleMatrix         = "matrix"
+	StyleLabel          = "label"
+	StyleForm           = "form"
+	StyleSimple         = "simple"
+	StyleSpaceDelimited = "spaceDelimited"
+	StylePipeDelimited  = "pipeDelimited"
+	StyleDeepObject     = "deepObject"
+
+	// StyleTabDelimited only arises from the Swagger 2.0 "tsv" collection format
+	StyleTabDelimited = "tabDelimited"
+)
+
+// Serialization returns the style and explode setting of the parameter,
+// applying the OpenAPI defaults for its location and translating the
+// Swagger 2.0 collectionFormat
+func (p *Parameter) Serialization() (style string, explode bool) {
+	switch p.CollectionFormat {
+	case "csv":
+		return p.defaultStyle(), false
+	case "ssv":
+		return StyleSpaceDelimited, false
+	case "tsv":
+		return StyleTabDelimited, false
+	case "pipes":
+		return StylePipeDelimited, false
+	case "multi":
+		return StyleForm, true
+	}
+
+	style = p.Style
+	if style == "" {
+		style = p.defaultStyle()
+	}
+
+	if p.Explode != nil {
+		return style, *p.Explode
+	}
+	if p.Type != "" && p.Schema == nil {
+		// Swagger 2.0 arrays default to csv
+		return style, false
+	}
+	return style, style == StyleForm
+}
+
+// defaultStyle returns the default style for the parameter location
+func (p *Parameter) defaultStyle() string {
+	switch p.In {
+	case "query", "cookie", "formData":
+		return StyleForm
+	default:
+		return StyleSimple
+	}
 }
 
 // RequestBody represents a request body
@@ -108,6 +259,9 @@ type Response struct {
 	Description string               `json:"description" yaml:"description"`
 	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
 	Headers     map[string]Header    `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Schema is the Swagger 2.0 response schema
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
 }
 
 // MediaType represents a media type
@@ -134,6 +288,11 @@ type Schema struct {
 	Required    []string           `json:"required,omitempty" yaml:"required,omitempty"`
 	Example     interface{}        `json:"example,omitempty" yaml:"example,omitempty"`
 	Enum        []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default     interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
 	Ref         string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 }
 
@@ -160,6 +319,61 @@ type SecurityScheme struct {
 	BearerFormat     string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
 	Flows            *Flows `json:"flows,omitempty" yaml:"flows,omitempty"`
 	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty" yaml:"openIdConnectUrl,omitempty"`
+
+	// Flow, AuthorizationURL, TokenURL and Scopes describe a Swagger 2.0
+	// oauth2 scheme; SecuritySchemes converts them to Flows
+	Flow             string            `json:"flow,omitempty" yaml:"flow,omitempty"`
+	AuthorizationURL string            `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// Security scheme types
+const (
+	SecurityTypeAPIKey        = "apiKey"
+	SecurityTypeHTTP          = "http"
+	SecurityTypeOAuth2        = "oauth2"
+	SecurityTypeOpenIDConnect = "openIdConnect"
+)
+
+// SecuritySchemes returns the security schemes of the spec by name. Swagger
+// 2.0 securityDefinitions are converted to their OpenAPI 3 form: basic
+// becomes an http scheme and oauth2 flows are moved into Flows.
+func (spec *OpenAPISpec) SecuritySchemes() map[string]SecurityScheme {
+	schemes := make(map[string]SecurityScheme)
+	if spec.Components != nil {
+		for name, scheme := range spec.Components.SecuritySchemes {
+			schemes[name] = scheme
+		}
+	}
+
+	for name, scheme := range spec.SecurityDefinitions {
+		switch scheme.Type {
+		case "basic":
+			scheme.Type = SecurityTypeHTTP
+			scheme.Scheme = "basic"
+		case SecurityTypeOAuth2:
+			flow := &Flow{
+				AuthorizationURL: scheme.AuthorizationURL,
+				TokenURL:         scheme.TokenURL,
+				Scopes:           scheme.Scopes,
+			}
+			scheme.Flows = &Flows{}
+			switch scheme.Flow {
+			case "implicit":
+				scheme.Flows.Implicit = flow
+			case "password":
+				scheme.Flows.Password = flow
+			case "application":
+				scheme.Flows.ClientCredentials = flow
+			case "accessCode":
+				scheme.Flows.AuthorizationCode = flow
+			}
+		}
+		schemes[name] = scheme
+	}
+
+	return schemes
 }
 
 // Flows represents OAuth2 flows
@@ -288,9 +502,28 @@ func (p *Parser) Parse(data []byte, filename string) (*OpenAPISpec, error) {
 		return nil, fmt.Errorf("invalid OpenAPI specification: %w", err)
 	}
 
+	spec.Raw = data
+	inheritConsumes(&spec)
+
 	return &spec, nil
 }
 
+// inheritConsumes applies the global Swagger 2.0 consumes to operations
+// that do not declare their own
+func inheritConsumes(spec *OpenAPISpec) {
+	if len(spec.Consumes) == 0 {
+		return
+	}
+	for _, pathItem := range spec.Paths {
+		for _, op := range []*Operation{pathItem.Get, pathItem.Put, pathItem.Post, pathItem.Delete,
+			pathItem.Options, pathItem.Head, pathItem.Patch, pathItem.Trace} {
+			if op != nil && len(op.Consumes) == 0 {
+				op.Consumes = spec.Consumes
+			}
+		}
+	}
+}
+
 // validateSpec performs basic validation on the OpenAPI spec
 func (p *Parser) validateSpec(spec *OpenAPISpec) error {
 	// Check version
@@ -315,7 +548,19 @@ func (p *Parser) validateSpec(spec *OpenAPISpec) error {
 	return nil
 }
 
-// GetOperations returns all operations from the spec
+// methodOrder is the order in which operations of one path are listed
+var methodOrder = map[string]int{
+	"GET":     0,
+	"HEAD":    1,
+	"POST":    2,
+	"PUT":     3,
+	"PATCH":   4,
+	"DELETE":  5,
+	"OPTIONS": 6,
+	"TRACE":   7,
+}
+
+// GetOperations returns all operations from the spec, sorted by path and method
 func (p *Parser) GetOperations(spec *OpenAPISpec) []OperationInfo {
 	var operations []OperationInfo
 
@@ -323,6 +568,13 @@ func (p *Parser) GetOperations(spec *OpenAPISpec) []OperationInfo {
 		operations = append(operations, p.extractOperations(path, pathItem)...)
 	}
 
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return methodOrder[operations[i].Method] < methodOrder[operations[j].Method]
+	})
+
 	return operations
 }
 
@@ -367,3 +619,74 @@ func (p *Parser) extractOperations(path string, pathItem PathItem) []OperationIn
 
 	return operations
 }
+
+// RequestBodyContent returns the request body media types of an operation.
+// A Swagger 2.0 body parameter is offered as its consumes media types, and
+// formData parameters as the properties of a form object.
+func (o *Operation) RequestBodyContent() map[string]MediaType {
+	if o.RequestBody != nil {
+		return o.RequestBody.Content
+	}
+
+	var form *Schema
+	hasFile := false
+	for _, param := range o.Parameters {
+		switch param.In {
+		case "body":
+			content := make(map[string]MediaType)
+			for _, mediaType := range o.consumes("application/json", nil) {
+				content[mediaType] = MediaType{Schema: param.Schema, Example: param.Example}
+			}
+			return content
+
+		case "formData":
+			if form == nil {
+				form = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			}
+			schema := param.EffectiveSchema()
+			if param.Type == "file" {
+				schema = &Schema{Type: "string", Format: "binary", Description: param.Description}
+				hasFile = true
+			}
+			form.Properties[param.Name] = schema
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+		}
+	}
+
+	if form == nil {
+		return nil
+	}
+
+	// Form parameters are sent as a form of the declared type
+	defaultType := "application/x-www-form-urlencoded"
+	if hasFile {
+		defaultType = "multipart/form-data"
+	}
+	content := make(map[string]MediaType)
+	for _, mediaType := range o.consumes(defaultType, isFormMediaType) {
+		content[mediaType] = MediaType{Schema: form}
+	}
+	return content
+}
+
+// consumes returns the declared Swagger 2.0 media types accepted by keep,
+// or fallback when there are none
+func (o *Operation) consumes(fallback string, keep func(string) bool) []string {
+	var mediaTypes []string
+	for _, mediaType := range o.Consumes {
+		if keep == nil || keep(mediaType) {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		return []string{fallback}
+	}
+	return mediaTypes
+}
+
+// isFormMediaType reports whether a media type carries form fields
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
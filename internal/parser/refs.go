@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strings"
+)
+
+// maxRefDepth bounds chains of $ref that point at other $refs
+const maxRefDepth = 32
+
+// ResolveSchema follows $ref until a concrete schema is found. Unresolvable
+// references are returned unchanged.
+func (p *Parser) ResolveSchema(spec *OpenAPISpec, schema *Schema) *Schema {
+	for depth := 0; schema != nil && schema.Ref != "" && depth < maxRefDepth; depth++ {
+		target := p.lookupSchema(spec, schema.Ref)
+		if target == nil {
+			return schema
+		}
+		schema = target
+	}
+	return schema
+}
+
+// ExpandSchema returns a copy of schema with every $ref inlined. Recursive
+// references are left as $ref at the point where they would repeat.
+func (p *Parser) ExpandSchema(spec *OpenAPISpec, schema *Schema) *Schema {
+	return p.expandSchema(spec, schema, make(map[string]bool))
+}
+
+// expandSchema inlines references, tracking the refs currently being expanded
+func (p *Parser) expandSchema(spec *OpenAPISpec, schema *Schema, visiting map[string]bool) *Schema {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		if visiting[schema.Ref] {
+			return schema
+		}
+		target := p.lookupSchema(spec, schema.Ref)
+		if target == nil {
+			return schema
+		}
+
+		visiting[schema.Ref] = true
+		defer delete(visiting, schema.Ref)
+		return p.expandSchema(spec, target, visiting)
+	}
+
+	expanded := *schema
+	if schema.Properties != nil {
+		expanded.Properties = make(map[string]*Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			expanded.Properties[name] = p.expandSchema(spec, property, visiting)
+		}
+	}
+	expanded.Items = p.expandSchema(spec, schema.Items, visiting)
+	expanded.AllOf = p.expandSchemas(spec, schema.AllOf, visiting)
+	expanded.OneOf = p.expandSchemas(spec, schema.OneOf, visiting)
+	expanded.AnyOf = p.expandSchemas(spec, schema.AnyOf, visiting)
+
+	return &expanded
+}
+
+// expandSchemas expands a list of schemas
+func (p *Parser) expandSchemas(spec *OpenAPISpec, schemas []*Schema, visiting map[string]bool) []*Schema {
+	if schemas == nil {
+		return nil
+	}
+
+	expanded := make([]*Schema, len(schemas))
+	for i, schema := range schemas {
+		expanded[i] = p.expandSchema(spec, schema, visiting)
+	}
+	return expanded
+}
+
+// lookupSchema finds the schema a local reference points to
+func (p *Parser) lookupSchema(spec *OpenAPISpec, ref string) *Schema {
+	switch {
+	case strings.HasPrefix(ref, "#/components/schemas/"):
+		if spec.Components == nil {
+			return nil
+		}
+		return spec.Components.Schemas[unescapePointer(strings.TrimPrefix(ref, "#/components/schemas/"))]
+	case strings.HasPrefix(ref, "#/definitions/"):
+		return spec.Definitions[unescapePointer(strings.TrimPrefix(ref, "#/definitions/"))]
+	default:
+		return nil
+	}
+}
+
+// unescapePointer decodes a JSON pointer token
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,154 @@
+package requester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// xmlEncodingPattern matches the encoding declared in an XML prolog
+var xmlEncodingPattern = regexp.MustCompile(`^<\?xml[^>]*\sencoding=["']([A-Za-z0-9._:-]+)["']`)
+
+// textMediaTypes are textual media types outside text/*
+var textMediaTypes = map[string]bool{
+	"application/javascript":            true,
+	"application/ecmascript":            true,
+	"application/x-www-form-urlencoded": true,
+	"application/yaml":                  true,
+	"application/x-yaml":                true,
+	"application/csv":                   true,
+	"application/graphql":               true,
+	"application/x-ndjson":              true,
+	"application/sql":                   true,
+}
+
+// mediaType returns the lowercased media type of a Content-Type value
+// without its parameters
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// IsJSONMediaType reports whether a media type or Content-Type value is JSON
+func IsJSONMediaType(contentType string) bool {
+	media := mediaType(contentType)
+	return media == "application/json" || strings.HasSuffix(media, "+json")
+}
+
+// IsTextMediaType reports whether a media type or Content-Type value carries
+// text. Images such as image/svg+xml are treated as binary so they can be
+// returned as images.
+func IsTextMediaType(contentType string) bool {
+	media := mediaType(contentType)
+	if strings.HasPrefix(media, "image/") {
+		return false
+	}
+	return strings.HasPrefix(media, "text/") ||
+		IsJSONMediaType(media) ||
+		media == "application/xml" || strings.HasSuffix(media, "+xml") ||
+		strings.HasSuffix(media, "+yaml") ||
+		textMediaTypes[media]
+}
+
+// decodeBody decodes a response body by its Content-Type, returning the body
+// and the media type it was decoded as. JSON is parsed, text is converted to
+// UTF-8 following its charset, and binary content is kept as []byte. Bodies
+// without a Content-Type are sniffed.
+func decodeBody(ctx context.Context, contentType string, data []byte) (interface{}, string) {
+	if len(data) == 0 {
+		return nil, mediaType(contentType)
+	}
+
+	if contentType == "" {
+		var body interface{}
+		if err := json.Unmarshal(data, &body); err == nil {
+			return body, "application/json"
+		}
+		contentType = http.DetectContentType(data)
+	}
+
+	media := mediaType(contentType)
+	if !IsTextMediaType(media) {
+		return data, media
+	}
+
+	text := decodeText(ctx, contentType, media, data)
+	if IsJSONMediaType(media) {
+		var body interface{}
+		if err := json.Unmarshal([]byte(text), &body); err == nil {
+			return body, media
+		}
+	}
+	return text, media
+}
+
+// decodeText converts text to UTF-8 using, in order, a byte order mark, the
+// charset parameter and the encoding declared by an XML prolog. Undeclared
+// text that is not valid UTF-8 is read as Windows-1252.
+func decodeText(ctx context.Context, contentType, media string, data []byte) string {
+	var enc encoding.Encoding
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	default:
+		enc = declaredEncoding(ctx, contentType, media, data)
+	}
+
+	if enc == nil {
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		enc = charmap.Windows1252
+	}
+
+	text, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		logger.Warn("Failed to decode response text",
+			logger.Session(ctx),
+			zap.String("content_type", contentType),
+			zap.Error(err))
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	return string(text)
+}
+
+// declaredEncoding returns the encoding named by the Content-Type charset or
+// an XML declaration, or nil if none is declared or it is unknown
+func declaredEncoding(ctx context.Context, contentType, media string, data []byte) encoding.Encoding {
+	var name string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		name = params["charset"]
+	}
+	if name == "" && (media == "application/xml" || media == "text/xml" || strings.HasSuffix(media, "+xml")) {
+		if match := xmlEncodingPattern.FindSubmatch(data); match != nil {
+			name = string(match[1])
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		logger.Warn("Unknown response charset",
+			logger.Session(ctx),
+			zap.String("charset", name))
+		return nil
+	}
+	return enc
+}
@@ -0,0 +1,71 @@
+package requester
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        string
+	}{
+		{name: "utf-8", contentType: "text/plain", data: []byte("café"), want: "café"},
+		{name: "utf-8 bom", contentType: "text/plain; charset=iso-8859-1", data: []byte("\xEF\xBB\xBFcafé"), want: "café"},
+		{name: "utf-16le bom", contentType: "text/plain", data: []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, want: "hi"},
+		{name: "utf-16be bom", contentType: "text/plain", data: []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, want: "hi"},
+		{name: "latin-1 charset", contentType: "text/plain; charset=ISO-8859-1", data: []byte("caf\xE9"), want: "café"},
+		{name: "quoted charset", contentType: `text/html; charset="windows-1252"`, data: []byte("\x93hi\x94"), want: "“hi”"},
+		{name: "gbk charset", contentType: "text/plain; charset=gbk", data: []byte{0xD6, 0xD0, 0xCE, 0xC4}, want: "中文"},
+		{name: "gb2312 label", contentType: "text/plain; charset=GB2312", data: []byte{0xD6, 0xD0, 0xCE, 0xC4}, want: "中文"},
+		{name: "shift_jis charset", contentType: "text/plain; charset=Shift_JIS", data: []byte{0x93, 0xFA, 0x96, 0x7B}, want: "日本"},
+		{name: "xml declaration", contentType: "application/xml", data: []byte(`<?xml version="1.0" encoding="ISO-8859-1"?><a>caf` + "\xE9</a>"), want: `<?xml version="1.0" encoding="ISO-8859-1"?><a>café</a>`},
+		{name: "charset wins over xml declaration", contentType: "text/xml; charset=utf-8", data: []byte(`<?xml version="1.0" encoding="ISO-8859-1"?><a>café</a>`), want: `<?xml version="1.0" encoding="ISO-8859-1"?><a>café</a>`},
+		{name: "xml declaration ignored for text", contentType: "text/plain", data: []byte(`<?xml version="1.0" encoding="ISO-8859-1"?>café`), want: `<?xml version="1.0" encoding="ISO-8859-1"?>café`},
+		{name: "undeclared invalid utf-8", contentType: "text/plain", data: []byte("caf\xE9"), want: "café"},
+		{name: "unknown charset", contentType: "text/plain; charset=x-unknown", data: []byte("café"), want: "café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeText(context.Background(), tt.contentType, mediaType(tt.contentType), tt.data)
+			if got != tt.want {
+				t.Errorf("decodeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        interface{}
+		wantMedia   string
+	}{
+		{name: "empty", contentType: "application/json", want: nil, wantMedia: "application/json"},
+		{name: "json", contentType: "application/json; charset=utf-8", data: []byte(`{"a":1}`), want: map[string]interface{}{"a": 1.0}, wantMedia: "application/json"},
+		{name: "json suffix", contentType: "application/problem+json", data: []byte(`{"a":1}`), want: map[string]interface{}{"a": 1.0}, wantMedia: "application/problem+json"},
+		{name: "json in latin-1", contentType: "application/json; charset=iso-8859-1", data: []byte(`{"a":"caf` + "\xE9" + `"}`), want: map[string]interface{}{"a": "café"}, wantMedia: "application/json"},
+		{name: "invalid json is text", contentType: "application/json", data: []byte("not json"), want: "not json", wantMedia: "application/json"},
+		{name: "yaml is text", contentType: "application/yaml", data: []byte("a: 1\n"), want: "a: 1\n", wantMedia: "application/yaml"},
+		{name: "binary", contentType: "application/octet-stream", data: []byte{0, 1, 2}, want: []byte{0, 1, 2}, wantMedia: "application/octet-stream"},
+		{name: "svg is an image", contentType: "image/svg+xml", data: []byte("<svg/>"), want: []byte("<svg/>"), wantMedia: "image/svg+xml"},
+		{name: "sniffed json", data: []byte(`[1,2]`), want: []interface{}{1.0, 2.0}, wantMedia: "application/json"},
+		{name: "sniffed text", data: []byte("hello"), want: "hello", wantMedia: "text/plain"},
+		{name: "sniffed binary", data: []byte("\x89PNG\r\n\x1a\n"), want: []byte("\x89PNG\r\n\x1a\n"), wantMedia: "image/png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, media := decodeBody(context.Background(), tt.contentType, tt.data)
+			if !reflect.DeepEqual(body, tt.want) || media != tt.wantMedia {
+				t.Errorf("decodeBody() = %#v, %q; want %#v, %q", body, media, tt.want, tt.wantMedia)
+			}
+		})
+	}
+}
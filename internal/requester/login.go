@@ -0,0 +1,190 @@
+package requester
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"go.uber.org/zap"
+)
+
+// loginTimeout is how long the login command waits for the redirect
+const loginTimeout = 5 * time.Minute
+
+// callbackPath is the path of the loopback redirect URI
+const callbackPath = "/callback"
+
+// authorizationResult is the outcome of the authorization redirect
+type authorizationResult struct {
+	code string
+	err  error
+}
+
+// Login runs the OAuth2 authorization code flow with PKCE (RFC 7636) for the
+// oauth2 auth settings. It listens for the redirect on a loopback address,
+// prints the authorization URL for the user to open, exchanges the code at
+// the token URL and saves the tokens to the token store, where the requester
+// finds and refreshes them.
+func Login(ctx context.Context, cfg *config.Config, schemes map[string]parser.SecurityScheme, out io.Writer) error {
+	auth := cfg.Auth
+	if auth.Type != "oauth2" {
+		return fmt.Errorf("login requires the oauth2 auth type, not %q", auth.Type)
+	}
+	if auth.ClientID == "" {
+		return fmt.Errorf("login requires an oauth2 client_id")
+	}
+
+	authorizationURL, tokenURL := auth.AuthorizationURL, auth.TokenURL
+	if name, flow := specFlow(schemes, pickAuthorizationCode); flow != nil {
+		if authorizationURL == "" {
+			authorizationURL = flow.AuthorizationURL
+		}
+		if tokenURL == "" {
+			tokenURL = flow.TokenURL
+		}
+		logger.Debug("Using OAuth2 authorization code flow from the specification", zap.String("scheme", name))
+	}
+	if authorizationURL == "" || tokenURL == "" {
+		return fmt.Errorf("no OAuth2 authorization and token URLs are configured or declared by the specification")
+	}
+
+	// Save the tokens under the token URL the requester resolves, which may
+	// belong to another flow than the one that issued the code
+	storeURL := auth.TokenURL
+	if storeURL == "" {
+		_, storeURL = specTokenURL(schemes, auth.ClientSecret)
+	}
+
+	store, err := newTokenStore(auth.TokenStore, auth.TokenStoreKey)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", auth.RedirectPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for the authorization redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath)
+
+	results := make(chan authorizationResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		result := callbackResult(r.URL.Query(), state)
+		if result.err != nil {
+			http.Error(w, "Authorization failed: "+result.err.Error(), http.StatusBadRequest)
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><body><p>Authorization complete. You can close this window.</p></body></html>")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	callbackServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go callbackServer.Serve(listener)
+	defer callbackServer.Close()
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {auth.ClientID},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if len(auth.Scopes) > 0 {
+		query.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	separator := "?"
+	if strings.Contains(authorizationURL, "?") {
+		separator = "&"
+	}
+
+	fmt.Fprintf(out, "Open this URL in a browser to authorize oas-mcp:\n\n  %s\n\nWaiting for the redirect to %s ...\n",
+		authorizationURL+separator+query.Encode(), redirectURI)
+
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+
+	var result authorizationResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return fmt.Errorf("no authorization redirect received: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	ts := newTokenSource(&http.Client{Timeout: tokenRequestTimeout}, auth.ClientID, auth.ClientSecret, tokenURL, auth.Scopes, "")
+	token, err := ts.postToken(ctx, tokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {result.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to exchange the authorization code: %w", err)
+	}
+
+	if err := store.Save(tokenStoreKey(auth.ClientID, storeURL), token.stored()); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Authorized. Tokens saved to %s\n", store.path)
+	if token.RefreshToken == "" {
+		fmt.Fprintln(out, "The authorization server issued no refresh token; run login again when the access token expires.")
+	}
+	return nil
+}
+
+// callbackResult reads the authorization response from the redirect query
+// (RFC 6749 section 4.1.2)
+func callbackResult(query url.Values, state string) authorizationResult {
+	if query.Get("state") != state {
+		return authorizationResult{err: errors.New("state mismatch in the authorization redirect")}
+	}
+	if code := query.Get("error"); code != "" {
+		message := code
+		if description := query.Get("error_description"); description != "" {
+			message += ": " + description
+		}
+		return authorizationResult{err: fmt.Errorf("authorization denied: %s", message)}
+	}
+	if query.Get("code") == "" {
+		return authorizationResult{err: errors.New("no authorization code in the redirect")}
+	}
+	return authorizationResult{code: query.Get("code")}
+}
+
+// randomString returns n random bytes encoded as unpadded base64url
+func randomString(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,430 @@
+package requester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"go.uber.org/zap"
+)
+
+// Token refresh timing
+const (
+	// maxRefreshWindow is how long before expiry a token is refreshed at most
+	maxRefreshWindow = time.Minute
+
+	// tokenRequestTimeout bounds a token request, which is shared by every
+	// caller waiting for it
+	tokenRequestTimeout = 30 * time.Second
+)
+
+// oauthToken is an access token with its expiry and refresh token
+type oauthToken struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+
+	// Expiry is zero if the token server did not say when the token expires
+	Expiry time.Time
+
+	// refreshAt is when the token is refreshed ahead of its expiry
+	refreshAt time.Time
+}
+
+// valid reports whether the token can still be used
+func (t *oauthToken) valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Before(t.Expiry))
+}
+
+// stale reports whether the token is due for a proactive refresh
+func (t *oauthToken) stale() bool {
+	return !t.refreshAt.IsZero() && time.Now().After(t.refreshAt)
+}
+
+// tokenFetch is a token request in flight, shared by all callers
+type tokenFetch struct {
+	done  chan struct{}
+	token *oauthToken
+	err   error
+}
+
+// tokenSource obtains OAuth2 access tokens with the client credentials or
+// refresh token grant and caches them in memory. Tokens are refreshed in the
+// background shortly before they expire, and concurrent callers share a
+// single token request. Tokens saved by the login command are loaded from
+// the token store, and refreshed tokens are written back to it.
+type tokenSource struct {
+	client       *http.Client
+	clientID     string
+	clientSecret string
+	scopes       []string
+	store        *tokenStore
+
+	mu           sync.Mutex
+	tokenURL     string
+	refreshToken string
+	token        *oauthToken
+	fetch        *tokenFetch
+	loaded       bool
+}
+
+// newTokenSource creates a token source for the oauth2 auth settings
+func newTokenSource(client *http.Client, clientID, clientSecret, tokenURL string, scopes []string, refreshToken string) *tokenSource {
+	return &tokenSource{
+		client:       client,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		tokenURL:     tokenURL,
+		refreshToken: refreshToken,
+	}
+}
+
+// useSpecTokenURL sets the token URL from the spec's oauth2 security schemes
+// unless one is configured
+func (ts *tokenSource) useSpecTokenURL(schemes map[string]parser.SecurityScheme) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.tokenURL != "" {
+		return
+	}
+
+	if name, tokenURL := specTokenURL(schemes, ts.clientSecret); tokenURL != "" {
+		ts.tokenURL = tokenURL
+		logger.Info("Using OAuth2 token URL from the specification",
+			zap.String("scheme", name),
+			zap.String("token_url", tokenURL))
+	}
+}
+
+// specTokenURL returns the token URL a client uses when none is configured.
+// The client credentials flow is preferred, then the authorization code flow;
+// public clients cannot use client credentials, so they prefer the
+// authorization code flow the login command uses. The login command saves
+// tokens under this URL so the requester finds them.
+func specTokenURL(schemes map[string]parser.SecurityScheme, clientSecret string) (string, string) {
+	flows := []flowPicker{pickClientCredentials, pickAuthorizationCode, pickPassword}
+	if clientSecret == "" {
+		flows = []flowPicker{pickAuthorizationCode, pickPassword, pickClientCredentials}
+	}
+	name, flow := specFlow(schemes, flows...)
+	if flow == nil {
+		return "", ""
+	}
+	return name, flow.TokenURL
+}
+
+// flowPicker selects one flow of an oauth2 security scheme
+type flowPicker func(*parser.Flows) *parser.Flow
+
+func pickClientCredentials(f *parser.Flows) *parser.Flow { return f.ClientCredentials }
+func pickAuthorizationCode(f *parser.Flows) *parser.Flow { return f.AuthorizationCode }
+func pickPassword(f *parser.Flows) *parser.Flow          { return f.Password }
+
+// specFlow returns the first oauth2 flow with a token URL, trying the flows in
+// order of preference and the schemes in name order
+func specFlow(schemes map[string]parser.SecurityScheme, flows ...flowPicker) (string, *parser.Flow) {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, pick := range flows {
+		for _, name := range names {
+			scheme := schemes[name]
+			if scheme.Type != parser.SecurityTypeOAuth2 || scheme.Flows == nil {
+				continue
+			}
+			if flow := pick(scheme.Flows); flow != nil && flow.TokenURL != "" {
+				return name, flow
+			}
+		}
+	}
+	return "", nil
+}
+
+// Token returns a valid access token, fetching one if none is cached. A
+// token close to expiry is returned while a new one is fetched in the
+// background.
+func (ts *tokenSource) Token(ctx context.Context) (*oauthToken, error) {
+	ts.mu.Lock()
+	if !ts.loaded {
+		ts.loadStored()
+	}
+	token := ts.token
+	if token.valid() {
+		if token.stale() {
+			ts.startFetch()
+		}
+		ts.mu.Unlock()
+		return token, nil
+	}
+	fetch := ts.startFetch()
+	ts.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Renew returns a token to retry a request the upstream rejected with the
+// given token. The rejected token is dropped and never returned again from
+// the cache: a token installed by a concurrent refresh is used if there is
+// one, otherwise a new token is fetched, joining a request already in flight.
+func (ts *tokenSource) Renew(ctx context.Context, rejected *oauthToken) (*oauthToken, error) {
+	ts.mu.Lock()
+	if ts.token != nil && ts.token.AccessToken == rejected.AccessToken {
+		ts.token = nil
+	}
+	if token := ts.token; token.valid() {
+		ts.mu.Unlock()
+		return token, nil
+	}
+	fetch := ts.startFetch()
+	ts.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadStored takes the token saved for this client and token URL from the
+// token store. The caller must hold ts.mu.
+func (ts *tokenSource) loadStored() {
+	ts.loaded = true
+	if ts.store == nil || ts.tokenURL == "" {
+		return
+	}
+
+	stored, err := ts.store.Load(tokenStoreKey(ts.clientID, ts.tokenURL))
+	if err != nil {
+		logger.Warn("Failed to load OAuth2 tokens from the token store", zap.Error(err))
+		return
+	}
+	if stored == nil {
+		return
+	}
+
+	ts.token = stored.oauthToken()
+	if stored.RefreshToken != "" {
+		ts.refreshToken = stored.RefreshToken
+	}
+	logger.Debug("Loaded OAuth2 token from the token store", zap.String("path", ts.store.path))
+}
+
+// save writes a token to the token store. Only tokens with a refresh token
+// are worth keeping across restarts.
+func (ts *tokenSource) save(tokenURL string, token *oauthToken) {
+	if ts.store == nil || token.RefreshToken == "" {
+		return
+	}
+	if err := ts.store.Save(tokenStoreKey(ts.clientID, tokenURL), token.stored()); err != nil {
+		logger.Warn("Failed to save OAuth2 token to the token store", zap.Error(err))
+	}
+}
+
+// startFetch starts a token request unless one is in flight and returns it.
+// The caller must hold ts.mu.
+func (ts *tokenSource) startFetch() *tokenFetch {
+	if ts.fetch != nil {
+		return ts.fetch
+	}
+
+	fetch := &tokenFetch{done: make(chan struct{})}
+	ts.fetch = fetch
+
+	refreshToken := ts.refreshToken
+	if ts.token != nil && ts.token.RefreshToken != "" {
+		refreshToken = ts.token.RefreshToken
+	}
+	tokenURL := ts.tokenURL
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+		defer cancel()
+
+		token, err := ts.requestToken(ctx, tokenURL, refreshToken)
+
+		ts.mu.Lock()
+		if err == nil {
+			ts.token = token
+			if token.RefreshToken != "" {
+				ts.refreshToken = token.RefreshToken
+			}
+		}
+		ts.fetch = nil
+		ts.mu.Unlock()
+
+		if err == nil {
+			ts.save(tokenURL, token)
+		}
+
+		fetch.token, fetch.err = token, err
+		close(fetch.done)
+	}()
+
+	return fetch
+}
+
+// requestToken uses the refresh token grant if a refresh token is known,
+// falling back to client credentials when it is rejected and a client
+// secret is configured
+func (ts *tokenSource) requestToken(ctx context.Context, tokenURL, refreshToken string) (*oauthToken, error) {
+	if tokenURL == "" {
+		return nil, fmt.Errorf("no OAuth2 token URL is configured or declared by the specification")
+	}
+
+	if refreshToken != "" {
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}}
+		token, err := ts.postToken(ctx, tokenURL, form)
+		if err == nil {
+			// The refresh token stays valid unless the server rotates it
+			if token.RefreshToken == "" {
+				token.RefreshToken = refreshToken
+			}
+			return token, nil
+		}
+		if ts.clientSecret == "" {
+			return nil, fmt.Errorf("%w (run \"oas-mcp login\" to authorize again)", err)
+		}
+		logger.Warn("OAuth2 refresh token grant failed, using client credentials",
+			zap.Error(err))
+	}
+
+	if ts.clientSecret == "" {
+		return nil, fmt.Errorf("no OAuth2 refresh token or client secret is available; run \"oas-mcp login\" to authorize")
+	}
+	return ts.postToken(ctx, tokenURL, url.Values{"grant_type": {"client_credentials"}})
+}
+
+// postToken sends a token request and parses the token response
+func (ts *tokenSource) postToken(ctx context.Context, tokenURL string, form url.Values) (*oauthToken, error) {
+	// Scopes of an authorization code were granted when it was issued
+	if len(ts.scopes) > 0 && form.Get("grant_type") != "authorization_code" {
+		form.Set("scope", strings.Join(ts.scopes, " "))
+	}
+	// Confidential clients authenticate with HTTP Basic, public clients
+	// identify themselves in the form
+	if ts.clientSecret == "" && ts.clientID != "" {
+		form.Set("client_id", ts.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if ts.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(ts.clientID), url.QueryEscape(ts.clientSecret))
+	}
+
+	logger.Debug("Requesting OAuth2 token",
+		zap.String("token_url", tokenURL),
+		zap.String("grant_type", form.Get("grant_type")))
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	return parseTokenResponse(resp.StatusCode, data)
+}
+
+// parseTokenResponse parses a token endpoint response (RFC 6749 section 5)
+func parseTokenResponse(statusCode int, data []byte) (*oauthToken, error) {
+	var body struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		RefreshToken     string      `json:"refresh_token"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d with an unreadable body", statusCode)
+	}
+
+	if statusCode >= 400 || body.Error != "" || body.AccessToken == "" {
+		message := body.Error
+		if body.ErrorDescription != "" {
+			message += ": " + body.ErrorDescription
+		}
+		if message == "" {
+			message = "no access token in response"
+		}
+		return nil, fmt.Errorf("token endpoint returned HTTP %d: %s", statusCode, message)
+	}
+
+	token := &oauthToken{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+	}
+	if seconds, err := strconv.ParseFloat(body.ExpiresIn.String(), 64); err == nil && seconds > 0 {
+		lifetime := time.Duration(seconds * float64(time.Second))
+		now := time.Now()
+		token.Expiry = now.Add(lifetime)
+		token.refreshAt = token.Expiry.Add(-min(lifetime/5, maxRefreshWindow))
+	}
+
+	return token, nil
+}
+
+// authorization returns the Authorization header value for a token
+func (t *oauthToken) authorization() string {
+	// Token types are case-insensitive; most servers only accept "Bearer"
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+// stored returns a token in the token store format
+func (t *oauthToken) stored() *storedToken {
+	return &storedToken{
+		AccessToken:  t.AccessToken,
+		TokenType:    t.TokenType,
+		RefreshToken: t.RefreshToken,
+		Expiry:       t.Expiry,
+	}
+}
+
+// oauthToken returns a stored token for use. Its original lifetime is
+// unknown, so it is refreshed a full refresh window ahead of expiry.
+func (t *storedToken) oauthToken() *oauthToken {
+	token := &oauthToken{
+		AccessToken:  t.AccessToken,
+		TokenType:    t.TokenType,
+		RefreshToken: t.RefreshToken,
+		Expiry:       t.Expiry,
+	}
+	if !t.Expiry.IsZero() {
+		token.refreshAt = t.Expiry.Add(-maxRefreshWindow)
+	}
+	return token
+}
@@ -0,0 +1,157 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+)
+
+// concurrentCallers is the number of callers racing for a token
+const concurrentCallers = 20
+
+// tokenServer issues the access tokens token-1, token-2, ... with the client
+// credentials grant, counting the token requests. Each response is delayed
+// so that concurrent callers overlap with the request in flight.
+func tokenServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			http.Error(w, `{"error": "unsupported_grant_type"}`, http.StatusBadRequest)
+			return
+		}
+		n := requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 3600}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// oauthRequester creates a requester for an upstream with client credentials
+// from a token endpoint
+func oauthRequester(t *testing.T, baseURL, tokenURL string) *Requester {
+	t.Helper()
+
+	return NewRequester(&config.Config{
+		Upstream: config.Upstream{BaseURL: baseURL, Timeout: 5},
+		Auth: config.Auth{
+			Type:         "oauth2",
+			ClientID:     "client",
+			ClientSecret: "secret",
+			TokenURL:     tokenURL,
+			TokenStore:   filepath.Join(t.TempDir(), "tokens.json"),
+		},
+	})
+}
+
+func TestTokenSharesOneRequestAmongConcurrentCallers(t *testing.T) {
+	var requests atomic.Int32
+	r := oauthRequester(t, "http://localhost", tokenServer(t, &requests).URL)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, concurrentCallers)
+	errs := make([]error, concurrentCallers)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := r.oauth.Token(context.Background())
+			if err == nil {
+				tokens[i] = token.AccessToken
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range tokens {
+		if errs[i] != nil || tokens[i] != "token-1" {
+			t.Errorf("caller %d got %q, %v; want token-1", i, tokens[i], errs[i])
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("token requests = %d, want 1", n)
+	}
+}
+
+func TestSendRetriesOnceWithRenewedToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// accepted reports whether the upstream accepts an Authorization header
+		accepted func(authorization string) bool
+		// wantStatus is the status every caller ends up with
+		wantStatus int
+		// wantUpstream is the number of upstream requests of all callers
+		wantUpstream func(rejected int) int
+	}{
+		{
+			name:         "first token rejected",
+			accepted:     func(authorization string) bool { return authorization == "Bearer token-2" },
+			wantStatus:   http.StatusOK,
+			wantUpstream: func(rejected int) int { return concurrentCallers + rejected },
+		},
+		{
+			name:         "every token rejected",
+			accepted:     func(string) bool { return false },
+			wantStatus:   http.StatusUnauthorized,
+			wantUpstream: func(int) int { return 2 * concurrentCallers },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokenRequests, upstreamRequests, rejected atomic.Int32
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamRequests.Add(1)
+				if !tt.accepted(r.Header.Get("Authorization")) {
+					rejected.Add(1)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{}`))
+			}))
+			defer upstream.Close()
+
+			r := oauthRequester(t, upstream.URL, tokenServer(t, &tokenRequests).URL)
+
+			var wg sync.WaitGroup
+			statuses := make([]int, concurrentCallers)
+			errs := make([]error, concurrentCallers)
+			for i := range statuses {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					response, err := r.Execute(context.Background(), &Request{Method: http.MethodGet, Path: "/items"})
+					if err == nil {
+						statuses[i] = response.StatusCode
+					}
+					errs[i] = err
+				}(i)
+			}
+			wg.Wait()
+
+			for i := range statuses {
+				if errs[i] != nil || statuses[i] != tt.wantStatus {
+					t.Errorf("caller %d got %d, %v; want %d", i, statuses[i], errs[i], tt.wantStatus)
+				}
+			}
+			if n := tokenRequests.Load(); n != 2 {
+				t.Errorf("token requests = %d, want 2: the first token and one renewal", n)
+			}
+			if n, want := int(upstreamRequests.Load()), tt.wantUpstream(int(rejected.Load())); n != want {
+				t.Errorf("upstream requests = %d, want %d", n, want)
+			}
+		})
+	}
+}
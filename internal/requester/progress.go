@@ -0,0 +1,107 @@
+package requester
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// ProgressFunc receives progress updates while a request is in flight.
+// total is zero when the final amount is not known in advance.
+type ProgressFunc func(progress, total float64, message string)
+
+// progressInterval limits how often body progress is reported
+const progressInterval = 250 * time.Millisecond
+
+// progressReader reports bytes or streamed chunks read from a response body
+type progressReader struct {
+	reader   io.Reader
+	progress ProgressFunc
+	total    float64
+	sse      bool
+	streamed bool
+
+	bytes        int64
+	chunks       int
+	lineLen      int
+	pendingEvent bool
+	lastReport   time.Time
+}
+
+// newProgressReader wraps a response body so that reads are reported to progress.
+// Event streams and newline-delimited JSON are reported as chunk counts,
+// everything else as bytes received.
+func newProgressReader(resp *http.Response, progress ProgressFunc) io.Reader {
+	if progress == nil {
+		return resp.Body
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	pr := &progressReader{
+		reader:   resp.Body,
+		progress: progress,
+		sse:      mediaType == "text/event-stream",
+	}
+	pr.streamed = pr.sse || mediaType == "application/x-ndjson" || mediaType == "application/stream+json"
+	if !pr.streamed && resp.ContentLength > 0 {
+		pr.total = float64(resp.ContentLength)
+	}
+
+	return pr
+}
+
+// Read implements io.Reader
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.bytes += int64(n)
+	if p.streamed {
+		p.countChunks(buf[:n])
+	}
+
+	if err == io.EOF || time.Since(p.lastReport) >= progressInterval {
+		p.report()
+	}
+
+	return n, err
+}
+
+// countChunks counts completed events (SSE) or non-empty lines (NDJSON)
+func (p *progressReader) countChunks(data []byte) {
+	for _, b := range data {
+		switch b {
+		case '\n':
+			if p.sse {
+				if p.lineLen == 0 && p.pendingEvent {
+					p.chunks++
+					p.pendingEvent = false
+				} else if p.lineLen > 0 {
+					p.pendingEvent = true
+				}
+			} else if p.lineLen > 0 {
+				p.chunks++
+			}
+			p.lineLen = 0
+		case '\r':
+		default:
+			p.lineLen++
+		}
+	}
+}
+
+// report sends the current progress
+func (p *progressReader) report() {
+	p.lastReport = time.Now()
+
+	if p.streamed {
+		if p.chunks > 0 {
+			p.progress(float64(p.chunks), 0, fmt.Sprintf("Received %d chunks", p.chunks))
+		}
+		return
+	}
+
+	if p.bytes > 0 {
+		p.progress(float64(p.bytes), p.total, fmt.Sprintf("Received %d bytes", p.bytes))
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/feitianbubu/oas-mcp/internal/config"
 	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -24,6 +28,12 @@ var Module = fx.Options(
 type Requester struct {
 	client *http.Client
 	config *config.Config
+
+	// oauth issues access tokens for the oauth2 auth type, or is nil
+	oauth *tokenSource
+
+	// schemes are the security schemes of the spec by name
+	schemes map[string]parser.SecurityScheme
 }
 
 // NewRequester creates a new requester instance
@@ -32,163 +42,388 @@ func NewRequester(cfg *config.Config) *Requester {
 		Timeout: time.Duration(cfg.Upstream.Timeout) * time.Second,
 	}
 
-	return &Requester{
+	r := &Requester{
 		client: client,
 		config: cfg,
 	}
+
+	auth := cfg.Auth
+	if auth.Type == "oauth2" && (auth.ClientID != "" || auth.RefreshToken != "") {
+		r.oauth = newTokenSource(client, auth.ClientID, auth.ClientSecret, auth.TokenURL, auth.Scopes, auth.RefreshToken)
+		if auth.ClientID != "" {
+			store, err := newTokenStore(auth.TokenStore, auth.TokenStoreKey)
+			if err != nil {
+				logger.Warn("OAuth2 token store is unavailable", zap.Error(err))
+			}
+			r.oauth.store = store
+		}
+	}
+
+	return r
+}
+
+// UseSecuritySchemes lets the requester take settings missing from the
+// configuration, such as the OAuth2 token URL, from the spec's security schemes
+func (r *Requester) UseSecuritySchemes(schemes map[string]parser.SecurityScheme) {
+	r.schemes = schemes
+	if r.oauth != nil {
+		r.oauth.useSpecTokenURL(schemes)
+	}
+
+	for name := range r.config.Auth.Credentials {
+		found := false
+		for scheme := range schemes {
+			found = found || strings.EqualFold(scheme, name)
+		}
+		if !found {
+			logger.Warn("Credentials configured for a security scheme the specification does not declare",
+				zap.String("scheme", name))
+		}
+	}
 }
 
 // Request represents an HTTP request
 type Request struct {
-	Method  string            `json:"method"`
-	Path    string            `json:"path"`
-	Headers map[string]string `json:"headers,omitempty"`
-	Query   map[string]string `json:"query,omitempty"`
-	Body    interface{}       `json:"body,omitempty"`
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Headers http.Header `json:"headers,omitempty"`
+	Query   url.Values  `json:"query,omitempty"`
+	Body    interface{} `json:"body,omitempty"`
+
+	// ContentType is the media type of Body. A []byte Body is sent as is;
+	// any other Body is encoded as JSON.
+	ContentType string `json:"content_type,omitempty"`
+
+	// Security lists the alternative security requirements of the operation.
+	// An empty list needs no authentication; nil applies the global auth
+	// settings.
+	Security []parser.SecurityRequirement `json:"-"`
+
+	// MaxBodyBytes, if positive, limits how much of the response body is read
+	MaxBodyBytes int64 `json:"-"`
+
+	// Progress, if set, receives updates while waiting on the upstream
+	Progress ProgressFunc `json:"-"`
 }
 
 // Response represents an HTTP response
 type Response struct {
-	StatusCode int               `json:"status_code"`
-	Headers    map[string]string `json:"headers"`
-	Body       interface{}       `json:"body"`
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers"`
+
+	// Body is the parsed JSON value, the text decoded to UTF-8, or []byte
+	// for binary content
+	Body interface{} `json:"body"`
+
+	// MediaType is the declared or sniffed media type of Body
+	MediaType string `json:"media_type,omitempty"`
+
+	// URL is the upstream URL the body was read from, without its query
+	URL string `json:"url,omitempty"`
+
+	// Truncated is set when the body was cut off at Request.MaxBodyBytes
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // Execute executes an HTTP request
 func (r *Requester) Execute(ctx context.Context, req *Request) (*Response, error) {
 	// Build the full URL
-	url := r.buildURL(req.Path, req.Query)
+	requestURL := r.buildURL(req.Path, req.Query)
 
 	// Prepare request body
-	var bodyReader io.Reader
-	if req.Body != nil {
-		bodyData, err := json.Marshal(req.Body)
+	var bodyData []byte
+	switch body := req.Body.(type) {
+	case nil:
+	case []byte:
+		bodyData = body
+	default:
+		var err error
+		bodyData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(bodyData)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	// Set headers
-	r.setHeaders(httpReq, req.Headers)
-
-	// Set authentication
-	r.setAuthentication(httpReq)
-
 	// Log request
 	logger.Debug("Executing HTTP request",
+		logger.Session(ctx),
 		zap.String("method", req.Method),
-		zap.String("url", url),
+		zap.String("url", requestURL),
 		zap.Any("headers", req.Headers),
 		zap.Any("query", req.Query))
 
 	// Execute request
-	httpResp, err := r.client.Do(httpReq)
+	httpResp, err := r.send(ctx, req, requestURL, bodyData)
 	if err != nil {
 		logger.Error("HTTP request failed",
+			logger.Session(ctx),
 			zap.String("method", req.Method),
-			zap.String("url", url),
+			zap.String("url", requestURL),
 			zap.Error(err))
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, err
+	}
+
+	// Follow asynchronous operations until they complete
+	httpResp, err = r.pollAsync(ctx, req, httpResp)
+	if err != nil {
+		return nil, err
 	}
 	defer httpResp.Body.Close()
 
-	// Read response body
-	respBody, err := io.ReadAll(httpResp.Body)
+	// Read response body, stopping one byte past the limit to detect larger bodies
+	reader := newProgressReader(httpResp, req.Progress)
+	if req.MaxBodyBytes > 0 {
+		reader = io.LimitReader(reader, req.MaxBodyBytes+1)
+	}
+	respBody, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// Parse response body
-	var body interface{}
-	if len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, &body); err != nil {
-			// If JSON parsing fails, return as string
-			body = string(respBody)
-		}
+	truncated := req.MaxBodyBytes > 0 && int64(len(respBody)) > req.MaxBodyBytes
+	if truncated {
+		respBody = respBody[:req.MaxBodyBytes]
+		logger.Warn("Response body exceeds the read limit and was truncated",
+			logger.Session(ctx),
+			zap.String("method", req.Method),
+			zap.String("url", requestURL),
+			zap.Int64("max_body_bytes", req.MaxBodyBytes))
 	}
 
-	// Extract response headers
-	headers := make(map[string]string)
-	for key, values := range httpResp.Header {
-		if len(values) > 0 {
-			headers[key] = values[0]
-		}
-	}
+	// Decode the body according to its Content-Type
+	body, media := decodeBody(ctx, httpResp.Header.Get("Content-Type"), respBody)
 
+	responseURL := *httpResp.Request.URL
+	responseURL.RawQuery = ""
+	responseURL.User = nil
+
+	// Keep every value of repeated response headers such as Set-Cookie and Link
 	response := &Response{
 		StatusCode: httpResp.StatusCode,
-		Headers:    headers,
+		Headers:    httpResp.Header.Clone(),
 		Body:       body,
+		MediaType:  media,
+		URL:        responseURL.String(),
+		Truncated:  truncated,
 	}
 
 	// Log response
 	logger.Debug("HTTP response received",
+		logger.Session(ctx),
 		zap.String("method", req.Method),
-		zap.String("url", url),
+		zap.String("url", requestURL),
 		zap.Int("status_code", response.StatusCode),
 		zap.Any("headers", response.Headers))
 
 	return response, nil
 }
 
-// buildURL builds the full URL from base URL, path, and query parameters
-func (r *Requester) buildURL(path string, query map[string]string) string {
-	url := r.config.Upstream.BaseURL
-	if url == "" {
-		url = "http://localhost"
+// send sends a request with authentication. A request rejected with 401
+// while using an OAuth2 token is retried once, after the rejected token is
+// replaced by one that a concurrent refresh installed or a new one.
+func (r *Requester) send(ctx context.Context, req *Request, requestURL string, bodyData []byte) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		var bodyReader io.Reader
+		if req.Body != nil {
+			bodyReader = bytes.NewReader(bodyData)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, requestURL, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+
+		// Set headers
+		if bodyReader != nil {
+			contentType := req.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			httpReq.Header.Set("Content-Type", contentType)
+		}
+		r.setHeaders(httpReq, req.Headers)
+
+		// Set authentication
+		token, err := r.setAuthentication(ctx, httpReq, req.Security)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+
+		httpResp, err := r.client.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP request failed: %w", err)
+		}
+
+		if httpResp.StatusCode != http.StatusUnauthorized || token == nil || attempt > 1 {
+			return httpResp, nil
+		}
+
+		logger.Info("Upstream rejected the OAuth2 token, retrying with a new token",
+			logger.Session(ctx),
+			zap.String("method", req.Method),
+			zap.String("url", requestURL))
+		httpResp.Body.Close()
+		if _, err := r.oauth.Renew(ctx, token); err != nil {
+			return nil, fmt.Errorf("failed to renew OAuth2 token: %w", err)
+		}
+	}
+}
+
+// pollAsync follows a 202 Accepted response by polling its Location header
+// until the operation completes or the configured attempt limit is reached
+func (r *Requester) pollAsync(ctx context.Context, req *Request, resp *http.Response) (*http.Response, error) {
+	maxAttempts := r.config.Upstream.PollMaxAttempts
+
+	for attempt := 1; attempt <= maxAttempts && resp.StatusCode == http.StatusAccepted; attempt++ {
+		location := resp.Header.Get("Location")
+		if location == "" {
+			location = resp.Header.Get("Operation-Location")
+		}
+		if location == "" {
+			return resp, nil
+		}
+
+		pollURL, err := resp.Request.URL.Parse(location)
+		if err != nil {
+			logger.Warn("Invalid asynchronous operation location",
+				logger.Session(ctx),
+				zap.String("location", location),
+				zap.Error(err))
+			return resp, nil
+		}
+
+		delay := r.retryAfter(resp)
+		resp.Body.Close()
+
+		if req.Progress != nil {
+			req.Progress(float64(attempt), float64(maxAttempts),
+				fmt.Sprintf("Waiting for asynchronous operation (attempt %d/%d)", attempt, maxAttempts))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		pollReq, err := http.NewRequestWithContext(ctx, http.MethodGet, pollURL.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create poll request: %w", err)
+		}
+		r.setHeaders(pollReq, req.Headers)
+
+		// Only send credentials back to the host we authenticated against
+		if pollURL.Host == resp.Request.URL.Host {
+			if _, err := r.setAuthentication(ctx, pollReq, req.Security); err != nil {
+				return nil, fmt.Errorf("failed to authenticate poll request: %w", err)
+			}
+		}
+
+		logger.Debug("Polling asynchronous operation",
+			logger.Session(ctx),
+			zap.String("url", pollURL.String()),
+			zap.Int("attempt", attempt))
+
+		resp, err = r.client.Do(pollReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll asynchronous operation: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// retryAfter returns the delay before the next poll, honoring Retry-After
+func (r *Requester) retryAfter(resp *http.Response) time.Duration {
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return time.Until(at)
+		}
+	}
+
+	return time.Duration(r.config.Upstream.PollInterval) * time.Second
+}
+
+// buildURL builds the full URL from base URL, path, and query parameters.
+// Query values are percent-encoded; repeated keys are kept.
+func (r *Requester) buildURL(path string, query url.Values) string {
+	requestURL := r.config.Upstream.BaseURL
+	if requestURL == "" {
+		requestURL = "http://localhost"
 	}
 
 	// Ensure URL ends with path
 	if path != "" {
-		if url[len(url)-1] != '/' && path[0] != '/' {
-			url += "/"
+		if requestURL[len(requestURL)-1] != '/' && path[0] != '/' {
+			requestURL += "/"
 		}
-		url += path
+		requestURL += path
 	}
 
 	// Add query parameters
 	if len(query) > 0 {
-		url += "?"
-		first := true
-		for key, value := range query {
-			if !first {
-				url += "&"
-			}
-			url += fmt.Sprintf("%s=%s", key, value)
-			first = false
+		separator := "?"
+		if strings.Contains(requestURL, "?") {
+			separator = "&"
 		}
+		requestURL += separator + query.Encode()
 	}
 
-	return url
+	return requestURL
 }
 
-// setHeaders sets request headers
-func (r *Requester) setHeaders(req *http.Request, headers map[string]string) {
+// setHeaders sets request headers, keeping every value of multi-value headers
+func (r *Requester) setHeaders(req *http.Request, headers http.Header) {
 	// Set default headers
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "oas-mcp/1.0")
 
-	// Set Content-Type for requests with body
-	if req.Body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	// Set custom headers
+	for key, values := range headers {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
+}
 
-	// Set custom headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
+// setAuthentication authenticates a request as its operation's security
+// requirements say, or with the global auth settings if the operation
+// declares none. It returns the OAuth2 token it used, if any.
+func (r *Requester) setAuthentication(ctx context.Context, req *http.Request, security []parser.SecurityRequirement) (*oauthToken, error) {
+	if security != nil {
+		credentials, ok, err := r.selectSecurity(security)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return r.applySecurity(ctx, req, credentials)
+		}
 	}
+	return r.setGlobalAuthentication(ctx, req)
 }
 
-// setAuthentication sets authentication headers based on configuration
-func (r *Requester) setAuthentication(req *http.Request) {
+// setGlobalAuthentication sets authentication headers based on the global
+// auth settings
+func (r *Requester) setGlobalAuthentication(ctx context.Context, req *http.Request) (*oauthToken, error) {
 	switch r.config.Auth.Type {
+	case "oauth2":
+		if r.oauth == nil {
+			// A static access token
+			if r.config.Auth.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+r.config.Auth.Token)
+			}
+			return nil, nil
+		}
+		token, err := r.oauth.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", token.authorization())
+		return token, nil
 	case "bearer":
 		if r.config.Auth.Token != "" {
 			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.config.Auth.Token))
@@ -209,6 +444,7 @@ func (r *Requester) setAuthentication(req *http.Request) {
 	case "none":
 		// No authentication
 	default:
-		logger.Warn("Unknown authentication type", zap.String("type", r.config.Auth.Type))
+		logger.Warn("Unknown authentication type", logger.Session(ctx), zap.String("type", r.config.Auth.Type))
 	}
+	return nil, nil
 }
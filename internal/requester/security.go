@@ -0,0 +1,224 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"go.uber.org/zap"
+)
+
+// schemeCredential is a credential for one security scheme of the spec
+type schemeCredential struct {
+	name       string
+	scheme     parser.SecurityScheme
+	credential config.Credential
+}
+
+// selectSecurity picks the first alternative of an operation's security
+// requirements whose schemes all have credentials. An empty list, or an
+// empty alternative when no other one can be satisfied, needs no
+// authentication. It returns false if nothing can be satisfied and the
+// global auth settings should be used instead.
+func (r *Requester) selectSecurity(requirements []parser.SecurityRequirement) ([]schemeCredential, bool, error) {
+	anonymous := len(requirements) == 0
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			anonymous = true
+			continue
+		}
+
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var credentials []schemeCredential
+		for _, name := range names {
+			credential, ok := r.schemeCredential(name)
+			if !ok {
+				credentials = nil
+				break
+			}
+			credentials = append(credentials, credential)
+		}
+		if credentials != nil {
+			return credentials, true, nil
+		}
+	}
+
+	if anonymous {
+		return nil, true, nil
+	}
+	// Without named credentials, fall back to the global auth settings
+	if len(r.config.Auth.Credentials) == 0 {
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("no credentials are configured for the security requirements of this operation (%s)", describeSecurity(requirements))
+}
+
+// schemeCredential returns the credential for a security scheme: the named
+// credential of the scheme, or the global auth settings if they suit it
+func (r *Requester) schemeCredential(name string) (schemeCredential, bool) {
+	scheme, ok := r.schemes[name]
+	if !ok {
+		return schemeCredential{}, false
+	}
+
+	credential, ok := r.namedCredential(name)
+	if !ok {
+		credential, ok = r.globalCredential(scheme)
+		if !ok {
+			return schemeCredential{}, false
+		}
+	}
+
+	usable := false
+	switch scheme.Type {
+	case parser.SecurityTypeAPIKey:
+		usable = scheme.Name != "" && apiKeyValue(credential) != "" &&
+			(strings.EqualFold(scheme.In, "header") || strings.EqualFold(scheme.In, "query") || strings.EqualFold(scheme.In, "cookie"))
+	case parser.SecurityTypeHTTP:
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
+			usable = credential.Token != ""
+		case "basic":
+			usable = credential.Username != ""
+		}
+	case parser.SecurityTypeOAuth2, parser.SecurityTypeOpenIDConnect:
+		usable = credential.Token != "" || r.oauth != nil
+	}
+	if !usable {
+		return schemeCredential{}, false
+	}
+
+	return schemeCredential{name: name, scheme: scheme, credential: credential}, true
+}
+
+// namedCredential returns the configured credential of a scheme. Scheme
+// names are matched case-insensitively as configuration keys are lowercased.
+func (r *Requester) namedCredential(name string) (config.Credential, bool) {
+	credentials := r.config.Auth.Credentials
+	if credential, ok := credentials[name]; ok {
+		return credential, true
+	}
+	for key, credential := range credentials {
+		if strings.EqualFold(key, name) {
+			return credential, true
+		}
+	}
+	return config.Credential{}, false
+}
+
+// globalCredential maps the global auth settings to a scheme of a matching
+// type, so existing configurations authenticate where the spec says
+func (r *Requester) globalCredential(scheme parser.SecurityScheme) (config.Credential, bool) {
+	auth := r.config.Auth
+	bearer := scheme.Type == parser.SecurityTypeOAuth2 || scheme.Type == parser.SecurityTypeOpenIDConnect ||
+		(scheme.Type == parser.SecurityTypeHTTP && strings.EqualFold(scheme.Scheme, "bearer"))
+
+	switch auth.Type {
+	case "apikey":
+		if scheme.Type == parser.SecurityTypeAPIKey {
+			return config.Credential{APIKey: auth.APIKey, Token: auth.Token}, true
+		}
+	case "bearer":
+		if bearer {
+			token := auth.Token
+			if token == "" {
+				token = auth.APIKey
+			}
+			return config.Credential{Token: token}, true
+		}
+	case "basic":
+		if scheme.Type == parser.SecurityTypeHTTP && strings.EqualFold(scheme.Scheme, "basic") {
+			return config.Credential{Username: auth.Username, Password: auth.Password}, true
+		}
+	case "oauth2":
+		if bearer {
+			// The token source, if any, issues the token
+			credential := config.Credential{}
+			if r.oauth == nil {
+				credential.Token = auth.Token
+			}
+			return credential, true
+		}
+	}
+	return config.Credential{}, false
+}
+
+// applySecurity authenticates a request for the selected schemes. It returns
+// the OAuth2 token it used, if any.
+func (r *Requester) applySecurity(ctx context.Context, req *http.Request, credentials []schemeCredential) (*oauthToken, error) {
+	var used *oauthToken
+	for _, c := range credentials {
+		switch c.scheme.Type {
+		case parser.SecurityTypeAPIKey:
+			value := apiKeyValue(c.credential)
+			switch strings.ToLower(c.scheme.In) {
+			case "header":
+				req.Header.Set(c.scheme.Name, value)
+			case "query":
+				// Append rather than re-encode to keep the serialized parameters
+				if req.URL.RawQuery != "" {
+					req.URL.RawQuery += "&"
+				}
+				req.URL.RawQuery += url.QueryEscape(c.scheme.Name) + "=" + url.QueryEscape(value)
+			case "cookie":
+				req.AddCookie(&http.Cookie{Name: c.scheme.Name, Value: value})
+			}
+		case parser.SecurityTypeHTTP:
+			if strings.EqualFold(c.scheme.Scheme, "basic") {
+				req.SetBasicAuth(c.credential.Username, c.credential.Password)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+c.credential.Token)
+			}
+		case parser.SecurityTypeOAuth2, parser.SecurityTypeOpenIDConnect:
+			if c.credential.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+c.credential.Token)
+				continue
+			}
+			token, err := r.oauth.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", token.authorization())
+			used = token
+		}
+
+		logger.Debug("Applied security scheme",
+			logger.Session(ctx),
+			zap.String("scheme", c.name),
+			zap.String("type", c.scheme.Type))
+	}
+	return used, nil
+}
+
+// apiKeyValue returns the API key of a credential, accepting a token too
+func apiKeyValue(credential config.Credential) string {
+	if credential.APIKey != "" {
+		return credential.APIKey
+	}
+	return credential.Token
+}
+
+// describeSecurity formats security requirements, e.g. "a and b, or c"
+func describeSecurity(requirements []parser.SecurityRequirement) string {
+	alternatives := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		alternatives = append(alternatives, strings.Join(names, " and "))
+	}
+	return strings.Join(alternatives, ", or ")
+}
@@ -0,0 +1,216 @@
+package requester
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Token store encryption settings
+const (
+	tokenStoreVersion = 1
+	tokenStoreKeySize = 32
+
+	// pbkdf2Iterations derives the key from a configured passphrase
+	pbkdf2Iterations = 210000
+)
+
+// storedToken is a token persisted by the login command
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// tokenStoreFile is the encrypted file format of the token store
+type tokenStoreFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt,omitempty"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// tokenStore persists OAuth2 tokens in a file encrypted with AES-GCM. The
+// key is derived from a configured passphrase, or else kept in a key file
+// next to the store that only the current user can read.
+type tokenStore struct {
+	mu         sync.Mutex
+	path       string
+	passphrase string
+}
+
+// newTokenStore creates a token store at path, defaulting to tokens.json in
+// the user's configuration directory
+func newTokenStore(path, passphrase string) (*tokenStore, error) {
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the token store: %w", err)
+		}
+		path = filepath.Join(dir, "oas-mcp", "tokens.json")
+	}
+	return &tokenStore{path: path, passphrase: passphrase}, nil
+}
+
+// tokenStoreKey identifies the tokens of a client at a token endpoint
+func tokenStoreKey(clientID, tokenURL string) string {
+	return clientID + "@" + tokenURL
+}
+
+// Load returns the stored token for a key, or nil if there is none
+func (st *tokenStore) Load(key string) (*storedToken, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	tokens, err := st.read()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[key], nil
+}
+
+// Save stores the token for a key
+func (st *tokenStore) Save(key string, token *storedToken) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	tokens, err := st.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = token
+	return st.write(tokens)
+}
+
+// read decrypts the store. A missing store holds no tokens.
+func (st *tokenStore) read() (map[string]*storedToken, error) {
+	tokens := make(map[string]*storedToken)
+
+	data, err := os.ReadFile(st.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token store: %w", err)
+	}
+
+	var file tokenStoreFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != tokenStoreVersion {
+		return nil, fmt.Errorf("token store %s is not in a supported format", st.path)
+	}
+
+	gcm, err := st.cipher(file.Salt, false)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token store %s: wrong key or corrupted file", st.path)
+	}
+
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token store: %w", err)
+	}
+	return tokens, nil
+}
+
+// write encrypts and replaces the store
+func (st *tokenStore) write(tokens map[string]*storedToken) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("failed to serialize tokens: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(st.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token store directory: %w", err)
+	}
+
+	file := tokenStoreFile{Version: tokenStoreVersion}
+	if st.passphrase != "" {
+		file.Salt = make([]byte, 16)
+		if _, err := rand.Read(file.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	gcm, err := st.cipher(file.Salt, true)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to serialize token store: %w", err)
+	}
+
+	// Replace the store atomically so a crash never leaves a partial file
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := os.Rename(tmp, st.path); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AES-GCM cipher of the store, creating the key file if
+// create is set and no passphrase is configured
+func (st *tokenStore) cipher(salt []byte, create bool) (cipher.AEAD, error) {
+	var key []byte
+	if st.passphrase != "" {
+		key = pbkdf2.Key([]byte(st.passphrase), salt, pbkdf2Iterations, tokenStoreKeySize, sha256.New)
+	} else {
+		var err error
+		key, err = st.keyFile(create)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyFile reads the random key kept next to the store, creating it if asked
+func (st *tokenStore) keyFile(create bool) ([]byte, error) {
+	path := st.path + ".key"
+
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != tokenStoreKeySize {
+			return nil, fmt.Errorf("token store key %s is invalid", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("failed to read token store key: %w", err)
+	}
+
+	key = make([]byte, tokenStoreKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate token store key: %w", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write token store key: %w", err)
+	}
+	return key, nil
+}
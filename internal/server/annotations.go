@@ -0,0 +1,86 @@
+package server
+
+import (
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// ToolAnnotations describes the behavior of a tool to clients
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
+}
+
+// generateAnnotations derives annotations from the HTTP method, then applies
+// x-mcp-* extensions of the operation and finally configured overrides
+func (s *Server) generateAnnotations(op parser.OperationInfo) *ToolAnnotations {
+	annotations := &ToolAnnotations{
+		Title:         op.Operation.Summary,
+		OpenWorldHint: boolPtr(true),
+	}
+
+	switch op.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		annotations.ReadOnlyHint = boolPtr(true)
+	case "PUT":
+		annotations.ReadOnlyHint = boolPtr(false)
+		annotations.DestructiveHint = boolPtr(false)
+		annotations.IdempotentHint = boolPtr(true)
+	case "DELETE":
+		annotations.ReadOnlyHint = boolPtr(false)
+		annotations.DestructiveHint = boolPtr(true)
+		annotations.IdempotentHint = boolPtr(true)
+	default:
+		annotations.ReadOnlyHint = boolPtr(false)
+		annotations.DestructiveHint = boolPtr(false)
+		annotations.IdempotentHint = boolPtr(false)
+	}
+
+	// Specification extensions
+	extensions := op.Operation.Extensions
+	if title, ok := extensions["x-mcp-title"].(string); ok {
+		annotations.Title = title
+	}
+	setHint(&annotations.ReadOnlyHint, extensions["x-mcp-read-only"])
+	setHint(&annotations.DestructiveHint, extensions["x-mcp-destructive"])
+	setHint(&annotations.IdempotentHint, extensions["x-mcp-idempotent"])
+	setHint(&annotations.OpenWorldHint, extensions["x-mcp-open-world"])
+
+	// Configured overrides
+	for _, override := range s.config.Tools.Annotations {
+		if override.Operation != op.OperationID {
+			continue
+		}
+		if override.Title != "" {
+			annotations.Title = override.Title
+		}
+		if override.ReadOnlyHint != nil {
+			annotations.ReadOnlyHint = override.ReadOnlyHint
+		}
+		if override.DestructiveHint != nil {
+			annotations.DestructiveHint = override.DestructiveHint
+		}
+		if override.IdempotentHint != nil {
+			annotations.IdempotentHint = override.IdempotentHint
+		}
+		if override.OpenWorldHint != nil {
+			annotations.OpenWorldHint = override.OpenWorldHint
+		}
+	}
+
+	return annotations
+}
+
+// setHint overrides a hint with an extension value if it is a boolean
+func setHint(hint **bool, value interface{}) {
+	if b, ok := value.(bool); ok {
+		*hint = boolPtr(b)
+	}
+}
+
+// boolPtr returns a pointer to b
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,436 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+)
+
+// bodyContentTypeArgument lets the caller choose the request media type
+// when an operation accepts several
+const bodyContentTypeArgument = "body_content_type"
+
+// fileValueHint describes how file contents are passed in arguments
+const fileValueHint = `Files are passed as base64 text, as {"base64": "...", "filename": "...", "content_type": "..."}, or as {"path": "..."} under an allowed upload directory.`
+
+// mediaTypePreference orders the media types chosen by default
+var mediaTypePreference = []func(string) bool{
+	requester.IsJSONMediaType,
+	func(mediaType string) bool { return mediaType == "application/x-www-form-urlencoded" },
+	func(mediaType string) bool { return mediaType == "multipart/form-data" },
+	func(mediaType string) bool { return strings.HasPrefix(mediaType, "text/") },
+}
+
+// fileValue is the content of a file part or binary body
+type fileValue struct {
+	data        []byte
+	filename    string
+	contentType string
+}
+
+// requestMediaTypes returns the request media types of an operation, sorted
+func requestMediaTypes(op *parser.OperationInfo) []string {
+	return sortedKeys(op.Operation.RequestBodyContent())
+}
+
+// selectMediaType returns the requested media type if the operation offers
+// it, otherwise the preferred one among those offered
+func selectMediaType(op *parser.OperationInfo, arguments map[string]interface{}) (string, error) {
+	mediaTypes := requestMediaTypes(op)
+	if len(mediaTypes) == 0 {
+		return "", nil
+	}
+
+	if requested, ok := arguments[bodyContentTypeArgument].(string); ok && requested != "" {
+		for _, mediaType := range mediaTypes {
+			if strings.EqualFold(mediaType, requested) {
+				return mediaType, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported %s %q (must be one of %s)", bodyContentTypeArgument, requested, strings.Join(mediaTypes, ", "))
+	}
+
+	for _, preferred := range mediaTypePreference {
+		for _, mediaType := range mediaTypes {
+			if preferred(mediaType) {
+				return mediaType, nil
+			}
+		}
+	}
+	return mediaTypes[0], nil
+}
+
+// requestBodyValue returns the body argument of an operation. Swagger 2.0
+// formData parameters are collected into a form object.
+func requestBodyValue(op *parser.OperationInfo, arguments map[string]interface{}) (interface{}, bool) {
+	if op.Operation.RequestBody != nil {
+		value, ok := arguments["body"]
+		return value, ok
+	}
+
+	var form map[string]interface{}
+	for _, param := range op.Operation.Parameters {
+		value, ok := arguments[param.Name]
+		if !ok {
+			continue
+		}
+		switch param.In {
+		case "body":
+			return value, true
+		case "formData":
+			if form == nil {
+				form = make(map[string]interface{})
+			}
+			form[param.Name] = value
+		}
+	}
+
+	return form, form != nil
+}
+
+// encodeRequestBody encodes the body argument for the selected media type,
+// returning the body and its Content-Type
+func (s *Server) encodeRequestBody(op *parser.OperationInfo, arguments map[string]interface{}) (interface{}, string, error) {
+	value, ok := requestBodyValue(op, arguments)
+	if !ok {
+		return nil, "", nil
+	}
+
+	mediaType, err := selectMediaType(op, arguments)
+	if err != nil {
+		return nil, "", err
+	}
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+
+	var schema *parser.Schema
+	if content, ok := op.Operation.RequestBodyContent()[mediaType]; ok {
+		schema = s.parser.ExpandSchema(s.spec, content.Schema)
+	}
+
+	switch {
+	case requester.IsJSONMediaType(mediaType) || mediaType == "*/*":
+		if mediaType == "*/*" {
+			mediaType = "application/json"
+		}
+		return value, mediaType, nil
+
+	case mediaType == "application/x-www-form-urlencoded":
+		data, err := encodeForm(value)
+		return data, mediaType, err
+
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return s.encodeMultipart(value, schema)
+
+	case requester.IsTextMediaType(mediaType) || isXMLMediaType(mediaType):
+		data, err := encodeText(mediaType, value)
+		return data, mediaType, err
+
+	default:
+		file, err := s.readFileValue(value)
+		if err != nil {
+			return nil, "", err
+		}
+		return file.data, mediaType, nil
+	}
+}
+
+// encodeText encodes a text body. Strings are sent as they are, and other
+// values are sent as JSON since text has no encoding of its own for them.
+// XML has to be passed as a string.
+func encodeText(mediaType string, value interface{}) ([]byte, error) {
+	if text, ok := value.(string); ok {
+		return []byte(text), nil
+	}
+	if isXMLMediaType(mediaType) {
+		return nil, fmt.Errorf("an %s body must be passed as an XML string", mediaType)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s body: %w", mediaType, err)
+	}
+	return data, nil
+}
+
+// encodeForm encodes an object as application/x-www-form-urlencoded.
+// Arrays repeat their field and nested objects are sent as JSON.
+func encodeForm(value interface{}) ([]byte, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a form body must be an object")
+	}
+
+	form := make(url.Values)
+	for _, name := range sortedKeys(object) {
+		for _, field := range formFieldValues(object[name]) {
+			form.Add(name, field)
+		}
+	}
+	return []byte(form.Encode()), nil
+}
+
+// formFieldValues formats a form field, one value per array item
+func formFieldValues(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, formFieldValues(item)...)
+		}
+		return values
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return []string{string(data)}
+	default:
+		return []string{formatScalar(v)}
+	}
+}
+
+// encodeMultipart encodes an object as multipart/form-data. Fields with a
+// binary schema, or given as file objects, become file parts.
+func (s *Server) encodeMultipart(value interface{}, schema *parser.Schema) (interface{}, string, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("a multipart body must be an object")
+	}
+
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+
+	for _, name := range sortedKeys(object) {
+		var property *parser.Schema
+		if schema != nil {
+			property = schema.Properties[name]
+		}
+
+		items := []interface{}{object[name]}
+		if array, ok := object[name].([]interface{}); ok {
+			items = array
+			if property != nil {
+				property = property.Items
+			}
+		}
+
+		for _, item := range items {
+			if err := s.writePart(writer, name, item, property); err != nil {
+				return nil, "", fmt.Errorf("field %s: %w", name, err)
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode multipart body: %w", err)
+	}
+	return b.Bytes(), writer.FormDataContentType(), nil
+}
+
+// writePart writes one multipart field
+func (s *Server) writePart(writer *multipart.Writer, name string, value interface{}, schema *parser.Schema) error {
+	if isFileSchema(schema) || isFileObject(value) {
+		file, err := s.readFileValue(value)
+		if err != nil {
+			return err
+		}
+
+		filename := file.filename
+		if filename == "" {
+			filename = name
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": filename}))
+		header.Set("Content-Type", file.contentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		_, err = part.Write(file.data)
+		return err
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": name}))
+		header.Set("Content-Type", "application/json")
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(part).Encode(object)
+	}
+
+	return writer.WriteField(name, formatScalar(value))
+}
+
+// readFileValue decodes a file given as base64 text, a {"base64": ...}
+// object, or a {"path": ...} object under an allowed upload directory
+func (s *Server) readFileValue(value interface{}) (*fileValue, error) {
+	file := &fileValue{contentType: "application/octet-stream"}
+
+	var encoded, path string
+	switch v := value.(type) {
+	case string:
+		encoded = v
+	case map[string]interface{}:
+		encoded, _ = v["base64"].(string)
+		path, _ = v["path"].(string)
+		if filename, ok := v["filename"].(string); ok {
+			file.filename = filename
+		}
+		if contentType, ok := v["content_type"].(string); ok && contentType != "" {
+			file.contentType = contentType
+		}
+	default:
+		return nil, fmt.Errorf("file content must be base64 text or a file object")
+	}
+
+	switch {
+	case path != "":
+		data, err := s.readUpload(path)
+		if err != nil {
+			return nil, err
+		}
+		file.data = data
+		if file.filename == "" {
+			file.filename = filepath.Base(path)
+		}
+		if detected := mime.TypeByExtension(filepath.Ext(path)); detected != "" && file.contentType == "application/octet-stream" {
+			file.contentType = detected
+		}
+	default:
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 file content: %w", err)
+		}
+		file.data = data
+	}
+
+	return file, nil
+}
+
+// readUpload reads a file by path, refusing anything outside the allowed
+// upload directories, including through symbolic links
+func (s *Server) readUpload(path string) ([]byte, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %s: %w", path, err)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %s: %w", path, err)
+	}
+
+	for _, dir := range s.config.Uploads.AllowedDirs {
+		root, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return os.ReadFile(resolved)
+		}
+	}
+
+	return nil, fmt.Errorf("file %s is not in an allowed upload directory", path)
+}
+
+// isFileSchema reports whether a schema describes binary file content
+func isFileSchema(schema *parser.Schema) bool {
+	return schema != nil && schema.Type == "string" && (schema.Format == "binary" || schema.Format == "base64")
+}
+
+// isFileObject reports whether a value is a file object
+func isFileObject(value interface{}) bool {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasBase64 := object["base64"]
+	_, hasPath := object["path"]
+	return hasBase64 || hasPath
+}
+
+// isXMLMediaType reports whether a media type carries XML
+func isXMLMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// bodyProperty describes the request body argument for the default media type
+func (s *Server) bodyProperty(op *parser.OperationInfo, description string) Property {
+	mediaType, _ := selectMediaType(op, nil)
+	property := Property{Type: "object", Description: description}
+
+	var schema *parser.Schema
+	if content, ok := op.Operation.RequestBodyContent()[mediaType]; ok {
+		schema = s.parser.ResolveSchema(s.spec, content.Schema)
+	}
+
+	var hints []string
+	switch {
+	case requester.IsJSONMediaType(mediaType):
+		if schema != nil && schema.Type != "" {
+			property.Type = schema.Type
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if hasFileProperty(schema) {
+			hints = append(hints, fileValueHint)
+		}
+	case requester.IsTextMediaType(mediaType) || isXMLMediaType(mediaType):
+		property.Type = "string"
+	default:
+		property.Type = "string"
+		hints = append(hints, fileValueHint)
+	}
+
+	if mediaTypes := requestMediaTypes(op); len(mediaTypes) > 1 {
+		hints = append(hints, fmt.Sprintf("Sent as %s unless %s selects another media type.", mediaType, bodyContentTypeArgument))
+	} else if mediaType != "" && !requester.IsJSONMediaType(mediaType) {
+		hints = append(hints, fmt.Sprintf("Sent as %s.", mediaType))
+	}
+
+	property.Description = strings.TrimSpace(strings.Join(append([]string{description}, hints...), " "))
+	return property
+}
+
+// hasFileProperty reports whether an object schema has binary properties
+func hasFileProperty(schema *parser.Schema) bool {
+	if schema == nil {
+		return false
+	}
+	for _, property := range schema.Properties {
+		if isFileSchema(property) || (property != nil && property.Type == "array" && isFileSchema(property.Items)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaTypeProperty lets the caller choose among the sorted request media types
+func mediaTypeProperty(mediaTypes []string) Property {
+	return Property{
+		Type:        "string",
+		Description: "Media type of the request body",
+		Enum:        mediaTypes,
+	}
+}
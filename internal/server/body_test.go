@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"testing"
+)
+
+const uploadSpec = `openapi: 3.0.3
+info: {title: Upload, version: "1"}
+paths:
+  /upload:
+    post:
+      operationId: upload
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+          application/x-www-form-urlencoded:
+            schema: {type: object}
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                file: {type: string, format: binary}
+          text/plain:
+            schema: {type: string}
+          application/yaml:
+            schema: {type: string}
+          application/xml:
+            schema: {type: string}
+          application/octet-stream:
+            schema: {type: string, format: binary}
+      responses:
+        "204": {description: Uploaded}
+`
+
+func TestEncodeRequestBody(t *testing.T) {
+	s := newTestServer(t, uploadSpec, testConfig("http://localhost"))
+	tool := s.catalog().tool("upload")
+	if tool == nil {
+		t.Fatal("upload tool not found")
+	}
+
+	tests := []struct {
+		name        string
+		mediaType   string
+		body        interface{}
+		contentType string
+		want        string
+		wantErr     bool
+	}{
+		{name: "json object", body: map[string]interface{}{"a": 1.0}, contentType: "application/json", want: `{"a":1}`},
+		{name: "json explicit", mediaType: "application/json", body: []interface{}{"x"}, contentType: "application/json", want: `["x"]`},
+		{name: "form", mediaType: "application/x-www-form-urlencoded", body: map[string]interface{}{"b": "x y", "a": []interface{}{1.0, true}}, contentType: "application/x-www-form-urlencoded", want: "a=1&a=true&b=x+y"},
+		{name: "form nested object as json", mediaType: "application/x-www-form-urlencoded", body: map[string]interface{}{"a": map[string]interface{}{"b": "c"}}, contentType: "application/x-www-form-urlencoded", want: "a=%7B%22b%22%3A%22c%22%7D"},
+		{name: "form rejects a string", mediaType: "application/x-www-form-urlencoded", body: "a=b", wantErr: true},
+		{name: "text string", mediaType: "text/plain", body: "hello", contentType: "text/plain", want: "hello"},
+		{name: "text number", mediaType: "text/plain", body: 1.5, contentType: "text/plain", want: "1.5"},
+		{name: "text boolean", mediaType: "text/plain", body: false, contentType: "text/plain", want: "false"},
+		{name: "text object as json", mediaType: "text/plain", body: map[string]interface{}{"a": "b"}, contentType: "text/plain", want: `{"a":"b"}`},
+		{name: "text array as json", mediaType: "text/plain", body: []interface{}{1.0, "x"}, contentType: "text/plain", want: `[1,"x"]`},
+		{name: "yaml string is text", mediaType: "application/yaml", body: "a: b\n", contentType: "application/yaml", want: "a: b\n"},
+		{name: "yaml object as json", mediaType: "application/yaml", body: map[string]interface{}{"a": "b"}, contentType: "application/yaml", want: `{"a":"b"}`},
+		{name: "xml string", mediaType: "application/xml", body: "<a/>", contentType: "application/xml", want: "<a/>"},
+		{name: "xml rejects an object", mediaType: "application/xml", body: map[string]interface{}{"a": "b"}, wantErr: true},
+		{name: "binary base64", mediaType: "application/octet-stream", body: "aGVsbG8=", contentType: "application/octet-stream", want: "hello"},
+		{name: "binary file object", mediaType: "application/octet-stream", body: map[string]interface{}{"base64": "aGk="}, contentType: "application/octet-stream", want: "hi"},
+		{name: "binary rejects invalid base64", mediaType: "application/octet-stream", body: "not base64!", wantErr: true},
+		{name: "unknown media type", mediaType: "image/png", body: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments := map[string]interface{}{"body": tt.body}
+			if tt.mediaType != "" {
+				arguments[bodyContentTypeArgument] = tt.mediaType
+			}
+
+			body, contentType, err := s.encodeRequestBody(tool.Operation, arguments)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("encodeRequestBody() = %v, want an error", body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encodeRequestBody() error = %v", err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("content type = %q, want %q", contentType, tt.contentType)
+			}
+
+			data, ok := body.([]byte)
+			if !ok {
+				if data, err = json.Marshal(body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if string(data) != tt.want {
+				t.Errorf("body = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMultipartBody(t *testing.T) {
+	s := newTestServer(t, uploadSpec, testConfig("http://localhost"))
+	tool := s.catalog().tool("upload")
+	if tool == nil {
+		t.Fatal("upload tool not found")
+	}
+
+	body, contentType, err := s.encodeRequestBody(tool.Operation, map[string]interface{}{
+		bodyContentTypeArgument: "multipart/form-data",
+		"body": map[string]interface{}{
+			"file":  map[string]interface{}{"base64": "aGVsbG8=", "filename": "hello.txt", "content_type": "text/plain"},
+			"meta":  map[string]interface{}{"a": "b"},
+			"count": 2.0,
+			"tags":  []interface{}{"x", "y"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("encodeRequestBody() error = %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("content type = %q, want multipart/form-data", contentType)
+	}
+
+	type part struct {
+		filename    string
+		contentType string
+		data        string
+	}
+	want := []struct {
+		name string
+		part part
+	}{
+		{"count", part{data: "2"}},
+		{"file", part{filename: "hello.txt", contentType: "text/plain", data: "hello"}},
+		{"meta", part{contentType: "application/json", data: "{\"a\":\"b\"}\n"}},
+		{"tags", part{data: "x"}},
+		{"tags", part{data: "y"}},
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body.([]byte)), params["boundary"])
+	for i, w := range want {
+		p, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := part{filename: p.FileName(), data: string(data)}
+		if p.FileName() != "" || p.Header.Get("Content-Type") == "application/json" {
+			got.contentType = p.Header.Get("Content-Type")
+		}
+		if p.FormName() != w.name || got != w.part {
+			t.Errorf("part %d = %s %+v, want %s %+v", i, p.FormName(), got, w.name, w.part)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("unexpected extra part: %v", err)
+	}
+}
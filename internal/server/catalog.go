@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// catalog is an immutable, indexed set of tools built once per spec
+// version. A new catalog replaces the old one as a whole; its tools are
+// never modified, so requests can keep using the catalog they started with.
+type catalog struct {
+	// tools are the operation tools, in the order of GetOperations
+	tools []Tool
+
+	// listed are the tools advertised by tools/list: the operation tools,
+	// or the meta-tools in discovery mode
+	listed []Tool
+
+	byName        map[string]*Tool
+	byOperationID map[string]*Tool
+
+	// version identifies the catalog in list cursors
+	version string
+
+	// payloads hold the pre-serialized listed tools, with and without
+	// their output schemas
+	payloads       []json.RawMessage
+	legacyPayloads []json.RawMessage
+
+	// full and legacyFull cache the unpaginated tools/list arrays, joined
+	// on first use
+	full, legacyFull         json.RawMessage
+	fullOnce, legacyFullOnce sync.Once
+}
+
+// newCatalog indexes the tools and serializes the tools/list entries
+func newCatalog(tools, listed []Tool) (*catalog, error) {
+	c := &catalog{
+		tools:          tools,
+		listed:         listed,
+		byName:         make(map[string]*Tool, len(listed)),
+		byOperationID:  make(map[string]*Tool, len(tools)),
+		version:        catalogVersion(listed),
+		payloads:       make([]json.RawMessage, len(listed)),
+		legacyPayloads: make([]json.RawMessage, len(listed)),
+	}
+
+	// The first tool wins when names or operationIds collide
+	for i := range listed {
+		if _, ok := c.byName[listed[i].Name]; !ok {
+			c.byName[listed[i].Name] = &listed[i]
+		}
+	}
+	for i := range tools {
+		if _, ok := c.byOperationID[tools[i].Operation.OperationID]; !ok {
+			c.byOperationID[tools[i].Operation.OperationID] = &tools[i]
+		}
+	}
+
+	legacy := withoutOutputSchemas(listed)
+	for i := range listed {
+		payload, err := json.Marshal(listed[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize tool %s: %w", listed[i].Name, err)
+		}
+		c.payloads[i] = payload
+
+		if listed[i].OutputSchema == nil {
+			c.legacyPayloads[i] = payload
+			continue
+		}
+		payload, err = json.Marshal(legacy[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize tool %s: %w", listed[i].Name, err)
+		}
+		c.legacyPayloads[i] = payload
+	}
+
+	return c, nil
+}
+
+// tool returns the listed tool with a name, or nil
+func (c *catalog) tool(name string) *Tool {
+	return c.byName[name]
+}
+
+// operation returns the tool of an operationId, or nil
+func (c *catalog) operation(operationID string) *Tool {
+	return c.byOperationID[operationID]
+}
+
+// listPayload returns the JSON array of the listed tools in [start, end).
+// Output schemas are only included for clients supporting structured output.
+// The full list is joined once and shared by later requests.
+func (c *catalog) listPayload(start, end int, withOutputSchemas bool) json.RawMessage {
+	payloads := c.legacyPayloads
+	if withOutputSchemas {
+		payloads = c.payloads
+	}
+	if start > 0 || end < len(payloads) {
+		return joinPayloads(payloads[start:end])
+	}
+
+	if withOutputSchemas {
+		c.fullOnce.Do(func() { c.full = joinPayloads(payloads) })
+		return c.full
+	}
+	c.legacyFullOnce.Do(func() { c.legacyFull = joinPayloads(payloads) })
+	return c.legacyFull
+}
+
+// joinPayloads joins serialized tools into a JSON array
+func joinPayloads(payloads []json.RawMessage) json.RawMessage {
+	size := 2 + len(payloads)
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+
+	var b bytes.Buffer
+	b.Grow(size)
+	b.WriteByte('[')
+	for i, payload := range payloads {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(payload)
+	}
+	b.WriteByte(']')
+
+	return b.Bytes()
+}
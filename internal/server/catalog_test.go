@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// benchmarkOperations is the size of the generated benchmark spec
+const benchmarkOperations = 5000
+
+// benchmarkSpec generates an OpenAPI 3 document with n operations: a get
+// and a post on n/2 paths, with parameters, a request body and a shared
+// response schema
+func benchmarkSpec(n int) []byte {
+	paths := make(map[string]interface{}, n/2)
+	for i := 0; i < n/2; i++ {
+		paths[fmt.Sprintf("/resources%d/{id}", i)] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": fmt.Sprintf("getResource%d", i),
+				"summary":     fmt.Sprintf("Get resource %d", i),
+				"tags":        []string{fmt.Sprintf("group%d", i%50)},
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}},
+					map[string]interface{}{"name": "expand", "in": "query", "schema": map[string]interface{}{"type": "boolean"}},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "The resource",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{"$ref": "#/components/schemas/Resource"},
+							},
+						},
+					},
+				},
+			},
+			"post": map[string]interface{}{
+				"operationId": fmt.Sprintf("updateResource%d", i),
+				"summary":     fmt.Sprintf("Update resource %d", i),
+				"tags":        []string{fmt.Sprintf("group%d", i%50)},
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}},
+				},
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{"$ref": "#/components/schemas/Resource"},
+						},
+					},
+				},
+				"responses": map[string]interface{}{
+					"204": map[string]interface{}{"description": "Updated"},
+				},
+			},
+		}
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": "Benchmark", "version": "1"},
+		"servers": []interface{}{map[string]interface{}{"url": "http://localhost"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Resource": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"id", "name"},
+					"properties": map[string]interface{}{
+						"id":      map[string]interface{}{"type": "string"},
+						"name":    map[string]interface{}{"type": "string", "description": "Display name"},
+						"created": map[string]interface{}{"type": "string", "format": "date-time"},
+						"labels":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// newBenchmarkServer creates a server for a generated spec of n operations
+// without building its catalog
+func newBenchmarkServer(b *testing.B, n int, cfg *config.Config) *Server {
+	b.Helper()
+
+	p := parser.NewParser()
+	spec, err := p.Parse(benchmarkSpec(n), "benchmark.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	filter, err := newOperationFilter(cfg.Tools)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return &Server{
+		config: cfg,
+		parser: p,
+		spec:   spec,
+		filter: filter,
+	}
+}
+
+func BenchmarkCatalogBuild(b *testing.B) {
+	s := newBenchmarkServer(b, benchmarkOperations, &config.Config{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.generateTools(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkToolLookup(b *testing.B) {
+	s := newBenchmarkServer(b, benchmarkOperations, &config.Config{})
+	if err := s.generateTools(); err != nil {
+		b.Fatal(err)
+	}
+
+	cat := s.catalog()
+	names := make([]string, len(cat.listed))
+	for i, tool := range cat.listed {
+		names[i] = tool.Name
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if s.catalog().tool(names[i%len(names)]) == nil {
+			b.Fatal("tool not found")
+		}
+	}
+}
+
+func BenchmarkToolsList(b *testing.B) {
+	for _, pageSize := range []int{0, 100} {
+		b.Run(fmt.Sprintf("page_size=%d", pageSize), func(b *testing.B) {
+			cfg := &config.Config{Server: config.Server{PageSize: pageSize}}
+			s := newBenchmarkServer(b, benchmarkOperations, cfg)
+			if err := s.generateTools(); err != nil {
+				b.Fatal(err)
+			}
+
+			sess := newSession(nil)
+			sess.setProtocolVersion(supportedProtocolVersions[0])
+			request := &MCPRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				response := s.handleToolsList(sess, request)
+				if response.Error != nil {
+					b.Fatal(response.Error.Message)
+				}
+				if err := json.NewEncoder(io.Discard).Encode(response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestToolsListPages(t *testing.T) {
+	cfg := testConfig("http://localhost")
+	s := newTestServer(t, string(benchmarkSpec(10)), cfg)
+	sess := testSession()
+
+	list := func(params map[string]interface{}) ([]interface{}, string) {
+		t.Helper()
+		response := s.handleToolsList(sess, &MCPRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list", Params: params})
+		if response.Error != nil {
+			t.Fatal(response.Error.Message)
+		}
+		result := response.Result.(map[string]interface{})
+		var tools []interface{}
+		if err := json.Unmarshal(result["tools"].(json.RawMessage), &tools); err != nil {
+			t.Fatal(err)
+		}
+		cursor, _ := result["nextCursor"].(string)
+		return tools, cursor
+	}
+
+	full, cursor := list(nil)
+	if len(full) != 10 || cursor != "" {
+		t.Fatalf("full list has %d tools and cursor %q, want 10 tools and no cursor", len(full), cursor)
+	}
+	if again, _ := list(nil); !reflect.DeepEqual(again, full) {
+		t.Error("cached full list differs from the first one")
+	}
+
+	cfg.Server.PageSize = 4
+	var paged []interface{}
+	for params := map[string]interface{}(nil); ; {
+		tools, next := list(params)
+		paged = append(paged, tools...)
+		if next == "" {
+			break
+		}
+		params = map[string]interface{}{"cursor": next}
+	}
+	if !reflect.DeepEqual(paged, full) {
+		t.Errorf("pages hold %d tools, want the %d tools of the full list", len(paged), len(full))
+	}
+}
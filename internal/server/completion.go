@@ -0,0 +1,363 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"go.uber.org/zap"
+)
+
+// maxCompletionValues is the maximum number of values in a completion result
+const maxCompletionValues = 100
+
+// defaultLookupTTL is used when a lookup does not set cache_ttl
+const defaultLookupTTL = 60 * time.Second
+
+// lookupCache caches values fetched by completion lookups
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[int]lookupEntry
+}
+
+// lookupEntry holds the cached values of one lookup
+type lookupEntry struct {
+	values  []string
+	expires time.Time
+}
+
+// validateCompletions checks that completion lookups reference known operations
+func (s *Server) validateCompletions() error {
+	for _, lookup := range s.config.Completion.Lookups {
+		if s.findOperation(lookup.Operation) == nil {
+			return fmt.Errorf("completion lookup for %s references unknown operation %s", lookup.Argument, lookup.Operation)
+		}
+	}
+	return nil
+}
+
+// handleCompletionComplete handles completion/complete requests
+func (s *Server) handleCompletionComplete(ctx context.Context, request *MCPRequest) *MCPResponse {
+	params, _ := request.Params.(map[string]interface{})
+	ref, _ := params["ref"].(map[string]interface{})
+	argument, _ := params["argument"].(map[string]interface{})
+	name, _ := argument["name"].(string)
+	value, _ := argument["value"].(string)
+
+	if ref == nil || name == "" {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Missing ref or argument name",
+			},
+		}
+	}
+
+	var candidates []string
+	refType, _ := ref["type"].(string)
+	switch refType {
+	case "ref/prompt":
+		promptName, _ := ref["name"].(string)
+		candidates = s.completePromptArgument(ctx, promptName, name)
+	case "ref/resource":
+		uri, _ := ref["uri"].(string)
+		candidates = s.completeResourceArgument(uri, name)
+	default:
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Unsupported reference type: %s", refType),
+			},
+		}
+	}
+
+	values := filterCompletions(candidates, value)
+	total := len(values)
+	if len(values) > maxCompletionValues {
+		values = values[:maxCompletionValues]
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"completion": map[string]interface{}{
+				"values":  values,
+				"total":   total,
+				"hasMore": total > len(values),
+			},
+		},
+	}
+}
+
+// completePromptArgument returns candidate values for a prompt argument.
+// Arguments of configured prompts are completed like the parameters of the
+// operations the prompt lists.
+func (s *Server) completePromptArgument(ctx context.Context, promptName, argument string) []string {
+	if promptName == callOperationPrompt && argument == "operation_id" {
+		return s.operationIDs()
+	}
+
+	var candidates []string
+	for _, prompt := range s.config.Prompts {
+		if prompt.Name != promptName {
+			continue
+		}
+		for _, operationID := range prompt.Operations {
+			tool := s.findOperationTool(operationID)
+			if tool == nil {
+				continue
+			}
+			candidates = append(candidates, s.operationArgumentValues(tool.Operation, argument)...)
+			candidates = append(candidates, s.lookupValues(ctx, tool.Name, argument)...)
+		}
+	}
+
+	return append(candidates, s.lookupValues(ctx, "", argument)...)
+}
+
+// completeResourceArgument returns candidate values for a resource template variable
+func (s *Server) completeResourceArgument(uri, argument string) []string {
+	switch {
+	case strings.HasPrefix(uri, operationResourcePrefix) && argument == "operationId":
+		return s.operationIDs()
+	case strings.HasPrefix(uri, tagResourcePrefix) && argument == "tag":
+		return s.tagNames()
+	}
+	return nil
+}
+
+// operationArgumentValues returns the enum, default and example values of
+// an operation parameter or top-level request body property, addressed as
+// "name" or "body.name"
+func (s *Server) operationArgumentValues(op *parser.OperationInfo, argument string) []string {
+	var values []interface{}
+	operation := op.Operation
+
+	// Parameters
+	for _, param := range operation.Parameters {
+		if param.Name != argument || param.In == "body" {
+			continue
+		}
+		values = append(values, schemaValues(s.parser.ResolveSchema(s.spec, param.EffectiveSchema()))...)
+		values = append(values, param.Example)
+	}
+
+	// Top-level request body properties
+	property := strings.TrimPrefix(argument, "body.")
+	for _, mediaType := range sortedKeys(operation.RequestBodyContent()) {
+		content := operation.RequestBodyContent()[mediaType]
+		if schema := s.parser.ResolveSchema(s.spec, content.Schema); schema != nil {
+			values = append(values, schemaValues(s.parser.ResolveSchema(s.spec, schema.Properties[property]))...)
+		}
+		if example, ok := content.Example.(map[string]interface{}); ok {
+			values = append(values, example[property])
+		}
+		if examples, ok := content.Examples.(map[string]interface{}); ok {
+			for _, name := range sortedKeys(examples) {
+				example, _ := examples[name].(map[string]interface{})
+				if value, ok := example["value"].(map[string]interface{}); ok {
+					values = append(values, value[property])
+				}
+			}
+		}
+	}
+
+	return scalarStrings(values)
+}
+
+// lookupValues fetches live values for an argument from the configured lookups.
+// An empty toolName matches only lookups that are not restricted to tools.
+func (s *Server) lookupValues(ctx context.Context, toolName, argument string) []string {
+	var values []string
+
+	for i, lookup := range s.config.Completion.Lookups {
+		if lookup.Argument != argument || !lookupAppliesTo(lookup, toolName) {
+			continue
+		}
+
+		fetched, err := s.fetchLookup(ctx, i, lookup)
+		if err != nil {
+			logger.Warn("Completion lookup failed",
+				logger.Session(ctx),
+				zap.String("argument", argument),
+				zap.String("operation", lookup.Operation),
+				zap.Error(err))
+			continue
+		}
+		values = append(values, fetched...)
+	}
+
+	return values
+}
+
+// lookupAppliesTo reports whether a lookup completes arguments of toolName
+func lookupAppliesTo(lookup config.CompletionLookup, toolName string) bool {
+	if len(lookup.Tools) == 0 {
+		return true
+	}
+	for _, name := range lookup.Tools {
+		if name == toolName {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchLookup calls the lookup operation, caching its values
+func (s *Server) fetchLookup(ctx context.Context, index int, lookup config.CompletionLookup) ([]string, error) {
+	s.lookups.mu.Lock()
+	entry, ok := s.lookups.entries[index]
+	s.lookups.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.values, nil
+	}
+
+	op := s.findOperation(lookup.Operation)
+	if op == nil {
+		return nil, fmt.Errorf("unknown operation: %s", lookup.Operation)
+	}
+
+	response, err := s.callOperation(ctx, op, lookup.Arguments, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("lookup operation returned status %d", response.StatusCode)
+	}
+
+	path, err := parseFieldPath(lookup.Values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lookup values path %q: %w", lookup.Values, err)
+	}
+	values := scalarStrings(extractPath(response.Body, path))
+
+	ttl := defaultLookupTTL
+	if lookup.CacheTTL > 0 {
+		ttl = time.Duration(lookup.CacheTTL) * time.Second
+	}
+
+	s.lookups.mu.Lock()
+	if s.lookups.entries == nil {
+		s.lookups.entries = make(map[int]lookupEntry)
+	}
+	s.lookups.entries[index] = lookupEntry{values: values, expires: time.Now().Add(ttl)}
+	s.lookups.mu.Unlock()
+
+	return values, nil
+}
+
+// operationIDs returns the operationIds of all exposed operations, sorted
+func (s *Server) operationIDs() []string {
+	tools := s.catalog().tools
+	ids := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		ids = append(ids, tool.Operation.OperationID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// extractPath collects the values at a field path. A [*] or * segment
+// iterates over all elements of an array or all values of an object.
+func extractPath(value interface{}, path []pathSegment) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+
+	segment, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if segment.all {
+			var values []interface{}
+			for _, key := range sortedKeys(v) {
+				values = append(values, extractPath(v[key], rest)...)
+			}
+			return values
+		}
+		if child, ok := v[segment.key]; ok && segment.key != "" {
+			return extractPath(child, rest)
+		}
+	case []interface{}:
+		if segment.all {
+			var values []interface{}
+			for _, item := range v {
+				values = append(values, extractPath(item, rest)...)
+			}
+			return values
+		}
+		if index, ok := segment.arrayIndex(); ok && index < len(v) {
+			return extractPath(v[index], rest)
+		}
+	}
+
+	return nil
+}
+
+// schemaValues returns the enum, example and default values of a schema
+func schemaValues(schema *parser.Schema) []interface{} {
+	if schema == nil {
+		return nil
+	}
+
+	values := append([]interface{}{}, schema.Enum...)
+	values = append(values, schema.Example, schema.Default)
+	if schema.Items != nil {
+		values = append(values, schemaValues(schema.Items)...)
+	}
+	return values
+}
+
+// scalarStrings formats scalar values as strings, dropping duplicates,
+// empty values and anything that is not a string, number or boolean
+func scalarStrings(values []interface{}) []string {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, value := range values {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case float64, int, int64, bool:
+			text = fmt.Sprintf("%v", v)
+		default:
+			continue
+		}
+
+		if text != "" && !seen[text] {
+			seen[text] = true
+			result = append(result, text)
+		}
+	}
+
+	return result
+}
+
+// filterCompletions returns the candidates starting with prefix, ignoring
+// case, without duplicates
+func filterCompletions(candidates []string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	seen := make(map[string]bool)
+	values := []string{}
+
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), prefix) {
+			continue
+		}
+		seen[candidate] = true
+		values = append(values, candidate)
+	}
+
+	return values
+}
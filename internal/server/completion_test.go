@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+)
+
+const chatSpec = `openapi: 3.0.3
+info: {title: Chat, version: "1"}
+tags:
+  - name: chat
+paths:
+  /chat:
+    post:
+      operationId: chat
+      tags: [chat]
+      parameters:
+        - name: region
+          in: query
+          schema: {type: string, enum: [eu, us]}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                model: {type: string, enum: [small, large], default: small}
+            example: {model: medium}
+      responses:
+        "200": {description: Answer}
+  /models:
+    get:
+      operationId: listModels
+      responses:
+        "200": {description: Models}
+`
+
+func TestCompletionComplete(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"id": "live-1"}, {"id": "live-2"}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Prompts = []config.Prompt{{
+		Name:       "ask",
+		Arguments:  []config.PromptArgument{{Name: "model"}, {Name: "region"}, {Name: "question"}},
+		Operations: []string{"chat"},
+		Template:   "{{ .Args.question }}",
+	}}
+	cfg.Completion.Lookups = []config.CompletionLookup{
+		{Argument: "model", Operation: "listModels", Values: "data.*.id", Tools: []string{"chat"}},
+		{Argument: "model", Operation: "listModels", Values: "data.0.id", Tools: []string{"other"}},
+	}
+	s := newTestServer(t, chatSpec, cfg)
+
+	tests := []struct {
+		name     string
+		ref      map[string]interface{}
+		argument string
+		value    string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "call_operation operation_id", ref: map[string]interface{}{"type": "ref/prompt", "name": "call_operation"}, argument: "operation_id", value: "c", want: []string{"chat"}},
+		{name: "prompt body property with lookup", ref: map[string]interface{}{"type": "ref/prompt", "name": "ask"}, argument: "model", want: []string{"small", "large", "medium", "live-1", "live-2"}},
+		{name: "prompt value prefix", ref: map[string]interface{}{"type": "ref/prompt", "name": "ask"}, argument: "model", value: "L", want: []string{"large", "live-1", "live-2"}},
+		{name: "prompt parameter", ref: map[string]interface{}{"type": "ref/prompt", "name": "ask"}, argument: "region", want: []string{"eu", "us"}},
+		{name: "prompt argument without values", ref: map[string]interface{}{"type": "ref/prompt", "name": "ask"}, argument: "question", want: []string{}},
+		{name: "restricted lookup skipped without operations", ref: map[string]interface{}{"type": "ref/prompt", "name": "explore_api"}, argument: "model", want: []string{}},
+		{name: "resource operationId", ref: map[string]interface{}{"type": "ref/resource", "uri": operationResourcePrefix + "{operationId}"}, argument: "operationId", want: []string{"chat", "listModels"}},
+		{name: "resource tag", ref: map[string]interface{}{"type": "ref/resource", "uri": tagResourcePrefix + "{tag}"}, argument: "tag", want: []string{"chat"}},
+		{name: "tool references are not supported", ref: map[string]interface{}{"type": "ref/tool", "name": "chat"}, argument: "model", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := s.handleCompletionComplete(context.Background(), &MCPRequest{
+				JSONRPC: "2.0",
+				ID:      1,
+				Method:  "completion/complete",
+				Params: map[string]interface{}{
+					"ref":      tt.ref,
+					"argument": map[string]interface{}{"name": tt.argument, "value": tt.value},
+				},
+			})
+			if tt.wantErr {
+				if response.Error == nil {
+					t.Fatalf("result = %v, want an error", response.Result)
+				}
+				return
+			}
+			if response.Error != nil {
+				t.Fatalf("error = %s", response.Error.Message)
+			}
+
+			completion := response.Result.(map[string]interface{})["completion"].(map[string]interface{})
+			if values := completion["values"].([]string); !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("values = %q, want %q", values, tt.want)
+			}
+		})
+	}
+}
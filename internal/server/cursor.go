@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// catalogVersion fingerprints the tool catalog so that cursors issued for
+// one catalog are rejected after it changes
+func catalogVersion(tools []Tool) string {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, tool := range tools {
+		encoder.Encode(tool)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// encodeCursor builds an opaque cursor pointing at offset in a catalog version
+func encodeCursor(version string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", version, offset)))
+}
+
+// decodeCursor returns the offset a cursor points at, failing if the cursor
+// is malformed or was issued for a different catalog version
+func decodeCursor(cursor, version string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+
+	cursorVersion, rawOffset, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	if cursorVersion != version {
+		return 0, fmt.Errorf("cursor is from a previous catalog; restart listing without a cursor")
+	}
+
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+
+	return offset, nil
+}
+
+// paginate returns the page bounds for a request and the cursor of the next
+// page, if any. A page size of zero returns everything in one page.
+func paginate(params interface{}, total, pageSize int, version string) (start, end int, nextCursor string, err error) {
+	p, _ := params.(map[string]interface{})
+	if cursor, ok := p["cursor"].(string); ok && cursor != "" {
+		start, err = decodeCursor(cursor, version)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		if start > total {
+			return 0, 0, "", fmt.Errorf("cursor is out of range")
+		}
+	}
+
+	end = total
+	if pageSize > 0 && start+pageSize < total {
+		end = start + pageSize
+		nextCursor = encodeCursor(version, end)
+	}
+
+	return start, end, nextCursor, nil
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+)
+
+// Names of the discovery mode meta-tools
+const (
+	searchOperationsTool  = "search_operations"
+	describeOperationTool = "describe_operation"
+	invokeOperationTool   = "invoke_operation"
+)
+
+// Search result limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Weights of the operation fields matched by search_operations
+const (
+	operationIDWeight = 3
+	pathWeight        = 2
+	summaryWeight     = 2
+	tagWeight         = 2
+	descriptionWeight = 1
+	parameterWeight   = 1
+)
+
+// operationSummary is one search_operations hit
+type operationSummary struct {
+	OperationID string   `json:"operation_id"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// metaTools returns the tools exposed in discovery mode
+func metaTools() []Tool {
+	return []Tool{
+		{
+			Name:        searchOperationsTool,
+			Description: "Search the API operations by keywords matched against operationIds, paths, summaries, descriptions, tags and parameter names. Use describe_operation to get the schema of a hit.",
+			InputSchema: Schema{
+				Type: "object",
+				Properties: map[string]Property{
+					"query": {Type: "string", Description: "Space-separated keywords; every keyword must match"},
+					"tag":   {Type: "string", Description: "Only return operations with this tag"},
+					"limit": {Type: "integer", Description: fmt.Sprintf("Maximum number of results (default %d, at most %d)", defaultSearchLimit, maxSearchLimit)},
+				},
+			},
+			Annotations: &ToolAnnotations{
+				Title:         "Search operations",
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+		{
+			Name:        describeOperationTool,
+			Description: "Describe one API operation: its method, path, documentation and the JSON schema of the arguments accepted by invoke_operation.",
+			InputSchema: Schema{
+				Type: "object",
+				Properties: map[string]Property{
+					"operation_id": {Type: "string", Description: "The operationId returned by search_operations"},
+				},
+				Required: []string{"operation_id"},
+			},
+			Annotations: &ToolAnnotations{
+				Title:         "Describe operation",
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+		{
+			Name:        invokeOperationTool,
+			Description: "Call an API operation by operationId. The arguments must match the input schema returned by describe_operation.",
+			InputSchema: Schema{
+				Type: "object",
+				Properties: map[string]Property{
+					"operation_id": {Type: "string", Description: "The operationId to call"},
+					"arguments":    {Type: "object", Description: "The operation arguments"},
+				},
+				Required: []string{"operation_id"},
+			},
+			Annotations: &ToolAnnotations{
+				Title:         "Invoke operation",
+				OpenWorldHint: boolPtr(true),
+			},
+		},
+	}
+}
+
+// callMetaTool runs a discovery mode meta-tool
+func (s *Server) callMetaTool(ctx context.Context, sess *session, name string, arguments map[string]interface{}, progress requester.ProgressFunc) *toolResult {
+	switch name {
+	case searchOperationsTool:
+		return s.searchOperations(arguments)
+	case describeOperationTool:
+		return s.describeOperation(arguments)
+	case invokeOperationTool:
+		return s.invokeOperation(ctx, sess, arguments, progress)
+	}
+	return &toolResult{Text: fmt.Sprintf("Unknown tool: %s", name), IsError: true}
+}
+
+// searchOperations ranks the operations matching all query keywords
+func (s *Server) searchOperations(arguments map[string]interface{}) *toolResult {
+	query, _ := arguments["query"].(string)
+	tag, _ := arguments["tag"].(string)
+
+	limit := defaultSearchLimit
+	if value, ok := arguments["limit"].(float64); ok && value >= 1 {
+		limit = int(value)
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+
+	type hit struct {
+		tool  *Tool
+		score int
+	}
+	var hits []hit
+
+	tools := s.catalog().tools
+	for i := range tools {
+		tool := &tools[i]
+		if tag != "" && !anyEqualFold(tool.Operation.Operation.Tags, tag) {
+			continue
+		}
+
+		score, ok := searchScore(tool, terms)
+		if ok {
+			hits = append(hits, hit{tool: tool, score: score})
+		}
+	}
+
+	// Operations are already sorted by path, which breaks ties
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	operations := []operationSummary{}
+	for _, h := range hits {
+		if len(operations) == limit {
+			break
+		}
+		op := h.tool.Operation
+		operations = append(operations, operationSummary{
+			OperationID: op.OperationID,
+			Method:      op.Method,
+			Path:        op.Path,
+			Summary:     op.Operation.Summary,
+			Tags:        op.Operation.Tags,
+		})
+	}
+
+	return jsonResult(map[string]interface{}{
+		"total":      len(hits),
+		"operations": operations,
+	})
+}
+
+// searchScore scores a tool against lowercase search terms. Every term must
+// match at least one field.
+func searchScore(tool *Tool, terms []string) (int, bool) {
+	op := tool.Operation
+
+	fields := []struct {
+		text   string
+		weight int
+	}{
+		{op.OperationID, operationIDWeight},
+		{op.Path, pathWeight},
+		{op.Operation.Summary, summaryWeight},
+		{strings.Join(op.Operation.Tags, " "), tagWeight},
+		{op.Operation.Description, descriptionWeight},
+	}
+	for _, param := range op.Operation.Parameters {
+		fields = append(fields, struct {
+			text   string
+			weight int
+		}{param.Name, parameterWeight})
+	}
+
+	total := 0
+	for _, term := range terms {
+		score := 0
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field.text), term) {
+				score += field.weight
+			}
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+
+	return total, true
+}
+
+// describeOperation returns the documentation and input schema of an operation
+func (s *Server) describeOperation(arguments map[string]interface{}) *toolResult {
+	operationID, _ := arguments["operation_id"].(string)
+	tool := s.findOperationTool(operationID)
+	if tool == nil {
+		return unknownOperationResult(operationID)
+	}
+
+	op := tool.Operation
+	description := map[string]interface{}{
+		"operation_id": op.OperationID,
+		"method":       op.Method,
+		"path":         op.Path,
+		"summary":      op.Operation.Summary,
+		"description":  op.Operation.Description,
+		"tags":         op.Operation.Tags,
+		"input_schema": tool.InputSchema,
+		"annotations":  tool.Annotations,
+	}
+	if tool.OutputSchema != nil {
+		description["output_schema"] = tool.OutputSchema
+	}
+
+	return jsonResult(description)
+}
+
+// invokeOperation calls an operation by operationId, with the same
+// elicitation and execution as a direct tool call
+func (s *Server) invokeOperation(ctx context.Context, sess *session, arguments map[string]interface{}, progress requester.ProgressFunc) *toolResult {
+	operationID, _ := arguments["operation_id"].(string)
+	tool := s.findOperationTool(operationID)
+	if tool == nil {
+		return unknownOperationResult(operationID)
+	}
+
+	operationArguments, _ := arguments["arguments"].(map[string]interface{})
+	if operationArguments == nil {
+		operationArguments = make(map[string]interface{})
+	}
+
+	operationArguments, failure := s.resolveMissingArguments(ctx, sess, tool, operationArguments)
+	if failure != nil {
+		return failure
+	}
+
+	operationArguments, violations := s.validateArguments(tool, operationArguments)
+	if len(violations) > 0 {
+		return invalidArgumentsResult(operationID, violations, fmt.Sprintf("Call %s for the input schema.", describeOperationTool))
+	}
+
+	result, err := s.executeTool(ctx, tool, operationArguments, progress)
+	if err != nil {
+		return &toolResult{Text: err.Error(), IsError: true}
+	}
+	return result
+}
+
+// findOperationTool returns the exposed tool of an operationId
+func (s *Server) findOperationTool(operationID string) *Tool {
+	return s.catalog().operation(operationID)
+}
+
+// unknownOperationResult reports an operationId that is not exposed
+func unknownOperationResult(operationID string) *toolResult {
+	return &toolResult{
+		Text:    fmt.Sprintf("Unknown operation: %q. Use %s to find operationIds.", operationID, searchOperationsTool),
+		IsError: true,
+	}
+}
+
+// jsonResult formats a value as an indented JSON tool result
+func jsonResult(value interface{}) *toolResult {
+	data, _ := json.MarshalIndent(value, "", "  ")
+	return &toolResult{Text: string(data)}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"go.uber.org/zap"
+)
+
+// elicitationCapability is the client capability required for elicitation/create
+const elicitationCapability = "elicitation"
+
+// elicitationFormats are the string formats allowed in elicitation schemas
+var elicitationFormats = map[string]bool{
+	"email":     true,
+	"uri":       true,
+	"date":      true,
+	"date-time": true,
+}
+
+// elicitationResult is the client's answer to elicitation/create
+type elicitationResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// missingParameters returns the required path and query parameters that
+// have no value in arguments. Path parameters are always required.
+func missingParameters(op *parser.OperationInfo, arguments map[string]interface{}) []parser.Parameter {
+	var missing []parser.Parameter
+
+	for _, param := range op.Operation.Parameters {
+		if param.In != "path" && (param.In != "query" || !param.Required) {
+			continue
+		}
+		if value, ok := arguments[param.Name]; ok && value != nil && value != "" {
+			continue
+		}
+		missing = append(missing, param)
+	}
+
+	return missing
+}
+
+// resolveMissingArguments makes sure the required path and query parameters
+// of a tool call have values. Clients that support elicitation are asked for
+// the missing values; otherwise a validation error result is returned.
+func (s *Server) resolveMissingArguments(ctx context.Context, sess *session, tool *Tool, arguments map[string]interface{}) (map[string]interface{}, *toolResult) {
+	missing := missingParameters(tool.Operation, arguments)
+	if len(missing) == 0 {
+		return arguments, nil
+	}
+
+	if !sess.hasCapability(elicitationCapability) {
+		return nil, missingParametersResult(tool, missing)
+	}
+
+	raw, err := sess.request(ctx, "elicitation/create", map[string]interface{}{
+		"message":         elicitationMessage(tool, missing),
+		"requestedSchema": s.elicitationSchema(missing),
+	})
+	if err != nil {
+		logger.Warn("Elicitation failed",
+			logger.Session(ctx),
+			zap.String("tool", tool.Name),
+			zap.Error(err))
+		return nil, missingParametersResult(tool, missing)
+	}
+
+	var result elicitationResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		logger.Warn("Invalid elicitation result",
+			logger.Session(ctx),
+			zap.String("tool", tool.Name),
+			zap.Error(err))
+		return nil, missingParametersResult(tool, missing)
+	}
+
+	if result.Action != "accept" {
+		return nil, &toolResult{
+			Text:    fmt.Sprintf("The call to %s was not made: the user chose to %s providing %s.", tool.Name, result.Action, parameterNames(missing)),
+			IsError: true,
+		}
+	}
+
+	completed := make(map[string]interface{}, len(arguments)+len(result.Content))
+	for key, value := range arguments {
+		completed[key] = value
+	}
+	for _, param := range missing {
+		if value, ok := result.Content[param.Name]; ok {
+			completed[param.Name] = value
+		}
+	}
+
+	if missing := missingParameters(tool.Operation, completed); len(missing) > 0 {
+		return nil, missingParametersResult(tool, missing)
+	}
+
+	return completed, nil
+}
+
+// elicitationMessage explains to the user which values are needed
+func elicitationMessage(tool *Tool, missing []parser.Parameter) string {
+	op := tool.Operation
+	message := fmt.Sprintf("%s %s", op.Method, op.Path)
+	if op.Operation.Summary != "" {
+		message += fmt.Sprintf(" (%s)", op.Operation.Summary)
+	}
+	return fmt.Sprintf("%s needs values for %s.", message, parameterNames(missing))
+}
+
+// elicitationSchema builds the flat object schema requested from the user.
+// Elicitation only supports primitive properties, so complex parameters are
+// requested as strings.
+func (s *Server) elicitationSchema(missing []parser.Parameter) map[string]interface{} {
+	properties := make(map[string]interface{}, len(missing))
+	required := make([]string, 0, len(missing))
+
+	for _, param := range missing {
+		property := map[string]interface{}{
+			"type":  "string",
+			"title": param.Name,
+		}
+		if param.Description != "" {
+			property["description"] = param.Description
+		}
+
+		if schema := s.parser.ResolveSchema(s.spec, param.EffectiveSchema()); schema != nil {
+			switch schema.Type {
+			case "integer", "number", "boolean":
+				property["type"] = schema.Type
+			case "string":
+				if elicitationFormats[schema.Format] {
+					property["format"] = schema.Format
+				}
+				if enum := scalarStrings(schema.Enum); len(enum) > 0 {
+					property["enum"] = enum
+				}
+			}
+			if schema.Default != nil {
+				property["default"] = schema.Default
+			}
+		}
+
+		properties[param.Name] = property
+		required = append(required, param.Name)
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// missingParametersResult reports missing parameters as a tool error
+func missingParametersResult(tool *Tool, missing []parser.Parameter) *toolResult {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Missing required parameters for %s:\n", tool.Name)
+	for _, param := range missing {
+		fmt.Fprintf(&b, "- %s (in %s)", param.Name, param.In)
+		if param.Description != "" {
+			fmt.Fprintf(&b, ": %s", param.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Provide these arguments and call the tool again.")
+
+	return &toolResult{
+		Text:    b.String(),
+		IsError: true,
+	}
+}
+
+// parameterNames lists parameter names for messages
+func parameterNames(params []parser.Parameter) string {
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	return strings.Join(names, ", ")
+}
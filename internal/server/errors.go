@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+)
+
+// maxErrorBodyLength limits how much of an error body is shown to the model
+const maxErrorBodyLength = 1000
+
+// upstreamErrorText explains a 4xx/5xx upstream response in a form the
+// model can act on: status, the spec's description of it, problem details
+// and a preview of the body
+func upstreamErrorText(op *parser.OperationInfo, response *requester.Response) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "The API returned HTTP %d %s for %s %s.\n",
+		response.StatusCode, http.StatusText(response.StatusCode), op.Method, op.Path)
+
+	if description := responseDescription(op.Operation, response.StatusCode); description != "" {
+		fmt.Fprintf(&b, "Documented meaning: %s\n", description)
+	}
+
+	if details := problemDetails(response.Body); details != "" {
+		fmt.Fprintf(&b, "Details: %s\n", details)
+	}
+
+	if hint := statusHint(response.StatusCode); hint != "" {
+		fmt.Fprintf(&b, "%s\n", hint)
+	}
+
+	if preview := bodyPreview(response.Body); preview != "" {
+		fmt.Fprintf(&b, "Response body: %s\n", preview)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// transportErrorText explains a request that never got a response
+func transportErrorText(op *parser.OperationInfo, err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Sprintf("The request %s %s timed out before the API responded. The operation may still complete on the server; check before retrying non-idempotent calls.",
+			op.Method, op.Path)
+	}
+	return fmt.Sprintf("The request %s %s could not be completed: %v", op.Method, op.Path, err)
+}
+
+// responseDescription returns the spec's description of a status code,
+// falling back to the status range (4XX) and the default response
+func responseDescription(operation *parser.Operation, statusCode int) string {
+	code := strconv.Itoa(statusCode)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, ok := operation.Responses[key]; ok && response.Description != "" {
+			return response.Description
+		}
+	}
+	return ""
+}
+
+// problemDetails extracts RFC 7807 problem details or common error fields
+func problemDetails(body interface{}) string {
+	object, ok := body.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	for _, key := range []string{"title", "detail", "message", "error", "error_description"} {
+		switch value := object[key].(type) {
+		case string:
+			if value != "" {
+				parts = append(parts, value)
+			}
+		case map[string]interface{}:
+			if nested := problemDetails(value); nested != "" {
+				parts = append(parts, nested)
+			}
+		}
+	}
+	if errs, ok := object["errors"]; ok {
+		data, _ := json.Marshal(errs)
+		parts = append(parts, "errors: "+string(data))
+	}
+
+	return strings.Join(parts, " - ")
+}
+
+// statusHint suggests what the model can do about a status code
+func statusHint(statusCode int) string {
+	switch {
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		return "Check the arguments against the tool's input schema and try again."
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return "The server's credentials were rejected or lack permission; retrying with the same arguments will not help."
+	case statusCode == http.StatusNotFound:
+		return "Check that identifiers in the path and query are correct."
+	case statusCode == http.StatusTooManyRequests:
+		return "The API is rate limiting requests; wait before retrying."
+	case statusCode >= 500:
+		return "The API failed to handle the request; retrying later may help."
+	default:
+		return ""
+	}
+}
+
+// bodyPreview renders a compact, truncated form of a response body
+func bodyPreview(body interface{}) string {
+	var text string
+	switch v := body.(type) {
+	case nil:
+		return ""
+	case string:
+		text = strings.TrimSpace(v)
+	case []byte:
+		return fmt.Sprintf("%d bytes of binary data", len(v))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		text = string(data)
+	}
+
+	if len(text) > maxErrorBodyLength {
+		text = truncateText(text, maxErrorBodyLength) + "..."
+	}
+	return text
+}
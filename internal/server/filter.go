@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// operationFilter decides which operations are exposed as tools
+type operationFilter struct {
+	readOnly bool
+	include  *compiledFilter
+	exclude  *compiledFilter
+}
+
+// compiledFilter is a config.ToolFilter with its patterns compiled
+type compiledFilter struct {
+	tags         []string
+	methods      []string
+	paths        []*regexp.Regexp
+	operationIDs []*regexp.Regexp
+	scopes       []string
+}
+
+// newOperationFilter compiles the include and exclude filters of the tools config
+func newOperationFilter(cfg config.Tools) (*operationFilter, error) {
+	include, err := compileFilter(cfg.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tools include filter: %w", err)
+	}
+	exclude, err := compileFilter(cfg.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tools exclude filter: %w", err)
+	}
+
+	return &operationFilter{
+		readOnly: cfg.ReadOnly,
+		include:  include,
+		exclude:  exclude,
+	}, nil
+}
+
+// compileFilter compiles path globs and operationId patterns
+func compileFilter(filter config.ToolFilter) (*compiledFilter, error) {
+	compiled := &compiledFilter{
+		tags:    filter.Tags,
+		methods: filter.Methods,
+		scopes:  filter.Scopes,
+	}
+
+	for _, glob := range filter.Paths {
+		compiled.paths = append(compiled.paths, globPattern(glob))
+	}
+
+	for _, pattern := range filter.OperationIDs {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation_ids pattern %q: %w", pattern, err)
+		}
+		compiled.operationIDs = append(compiled.operationIDs, re)
+	}
+
+	return compiled, nil
+}
+
+// globPattern converts a path glob into a regular expression. "*" and "?"
+// match within one path segment, "**" matches across segments.
+func globPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// allows reports whether an operation is exposed. An operation must match
+// every non-empty include criterion and no non-empty exclude criterion.
+func (f *operationFilter) allows(spec *parser.OpenAPISpec, op *parser.OperationInfo) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.readOnly && !strings.EqualFold(op.Method, "GET") && !strings.EqualFold(op.Method, "HEAD") {
+		return false
+	}
+
+	scopes := operationScopes(spec, op)
+
+	include := f.include
+	if len(include.tags) > 0 && !anyEqual(include.tags, op.Operation.Tags) {
+		return false
+	}
+	if len(include.methods) > 0 && !anyEqualFold(include.methods, op.Method) {
+		return false
+	}
+	if len(include.paths) > 0 && !anyMatch(include.paths, op.Path) {
+		return false
+	}
+	if len(include.operationIDs) > 0 && !anyMatch(include.operationIDs, op.OperationID) {
+		return false
+	}
+	if len(include.scopes) > 0 && !anyEqual(include.scopes, scopes) {
+		return false
+	}
+
+	exclude := f.exclude
+	switch {
+	case anyEqual(exclude.tags, op.Operation.Tags),
+		anyEqualFold(exclude.methods, op.Method),
+		anyMatch(exclude.paths, op.Path),
+		anyMatch(exclude.operationIDs, op.OperationID),
+		anyEqual(exclude.scopes, scopes):
+		return false
+	}
+
+	return true
+}
+
+// operationScopes returns the scopes named by the security requirements of
+// an operation, falling back to the global requirements of the spec
+func operationScopes(spec *parser.OpenAPISpec, op *parser.OperationInfo) []string {
+	requirements := op.Operation.Security
+	if requirements == nil {
+		requirements = spec.Security
+	}
+
+	var scopes []string
+	for _, requirement := range requirements {
+		for _, name := range sortedKeys(requirement) {
+			scopes = append(scopes, requirement[name]...)
+		}
+	}
+	return scopes
+}
+
+// anyEqual reports whether any of values is in list
+func anyEqual(list, values []string) bool {
+	for _, value := range values {
+		for _, item := range list {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyEqualFold reports whether value is in list, ignoring case
+func anyEqualFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyMatch reports whether value matches any of patterns
+func anyMatch(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
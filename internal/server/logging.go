@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultSessionLogLevel is the level forwarded to clients that never call logging/setLevel
+const defaultSessionLogLevel = zapcore.WarnLevel
+
+// mcpLogLevels maps MCP (syslog) log levels to zap levels
+var mcpLogLevels = map[string]zapcore.Level{
+	"debug":     zapcore.DebugLevel,
+	"info":      zapcore.InfoLevel,
+	"notice":    zapcore.InfoLevel,
+	"warning":   zapcore.WarnLevel,
+	"error":     zapcore.ErrorLevel,
+	"critical":  zapcore.DPanicLevel,
+	"alert":     zapcore.PanicLevel,
+	"emergency": zapcore.FatalLevel,
+}
+
+// mcpLogLevel maps a zap level to the MCP log level
+func mcpLogLevel(level zapcore.Level) string {
+	switch level {
+	case zapcore.DebugLevel:
+		return "debug"
+	case zapcore.InfoLevel:
+		return "info"
+	case zapcore.WarnLevel:
+		return "warning"
+	case zapcore.ErrorLevel:
+		return "error"
+	case zapcore.DPanicLevel:
+		return "critical"
+	case zapcore.PanicLevel:
+		return "alert"
+	default:
+		return "emergency"
+	}
+}
+
+// forwardLogs subscribes the session to log records at or above its level.
+// The session receives its own records and those about the server itself,
+// never the records of other sessions.
+func (s *session) forwardLogs() {
+	s.subscription = logger.Subscribe(s.logLevel, func(record logger.Record) {
+		if record.Session != "" && record.Session != s.id {
+			return
+		}
+		s.mu.Lock()
+		enabled := record.Level >= s.logLevel
+		s.mu.Unlock()
+		if !enabled {
+			return
+		}
+
+		data := make(map[string]interface{}, len(record.Fields)+1)
+		for key, value := range record.Fields {
+			data[key] = value
+		}
+		data["message"] = record.Message
+
+		// Failures are not logged, which would feed back into this subscription
+		s.write(&MCPNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/message",
+			Params: map[string]interface{}{
+				"level":  mcpLogLevel(record.Level),
+				"logger": "oas-mcp",
+				"data":   data,
+			},
+		})
+	})
+}
+
+// forwardsLogs reports whether the session receives log messages
+func (s *session) forwardsLogs() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscription != nil
+}
+
+// handleLoggingSetLevel handles logging/setLevel requests. The level only
+// selects the records forwarded to the session; the configured console and
+// file level is left alone.
+func (s *Server) handleLoggingSetLevel(sess *session, request *MCPRequest) *MCPResponse {
+	if !sess.forwardsLogs() {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32601,
+				Message: "Method not found: logging is not available over this transport",
+			},
+		}
+	}
+
+	params, _ := request.Params.(map[string]interface{})
+	name, _ := params["level"].(string)
+
+	level, ok := mcpLogLevels[name]
+	if !ok {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid log level: %s", name),
+			},
+		}
+	}
+
+	sess.mu.Lock()
+	sess.logLevel = level
+	subscription := sess.subscription
+	sess.mu.Unlock()
+	if subscription != nil {
+		subscription.SetLevel(level)
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  map[string]interface{}{},
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoggingOnlyForSessionsThatForwardLogs(t *testing.T) {
+	s := newTestServer(t, itemsSpec, testConfig("http://127.0.0.1"))
+
+	tests := []struct {
+		name        string
+		session     func() *session
+		wantLogging bool
+	}{
+		{
+			name: "stdio",
+			session: func() *session {
+				sess := newSession(func(interface{}) error { return nil })
+				sess.forwardLogs()
+				return sess
+			},
+			wantLogging: true,
+		},
+		{
+			name:    "http",
+			session: func() *session { return newSession(nil) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := tt.session()
+			defer sess.close()
+
+			initialize := s.handleRequest(context.Background(), sess, &MCPRequest{ID: 1, Method: "initialize", Params: map[string]interface{}{}})
+			capabilities := initialize.Result.(map[string]interface{})["capabilities"].(map[string]interface{})
+			if _, ok := capabilities["logging"]; ok != tt.wantLogging {
+				t.Errorf("logging capability = %v, want %v", ok, tt.wantLogging)
+			}
+
+			setLevel := s.handleRequest(context.Background(), sess, &MCPRequest{ID: 2, Method: "logging/setLevel", Params: map[string]interface{}{"level": "debug"}})
+			if (setLevel.Error == nil) != tt.wantLogging {
+				t.Errorf("logging/setLevel error = %v, want error %v", setLevel.Error, !tt.wantLogging)
+			}
+		})
+	}
+}
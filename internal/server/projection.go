@@ -0,0 +1,368 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// Reserved arguments that shape the response body instead of the request
+const (
+	fieldsArgument = "_fields"
+	limitArgument  = "_limit"
+)
+
+// pathSegment is one step of a field path: an object key, every array item
+// or object value ([*] or *) or one array item ([n]). A numeric key also
+// selects an array item, so items.0 and items[0] are the same path.
+type pathSegment struct {
+	key   string
+	all   bool
+	index int
+}
+
+// arrayIndex returns the array item a segment addresses, if any
+func (segment pathSegment) arrayIndex() (int, bool) {
+	if segment.all {
+		return 0, false
+	}
+	if segment.key == "" {
+		return segment.index, true
+	}
+	index, err := strconv.Atoi(segment.key)
+	return index, err == nil && index >= 0
+}
+
+// indexedItems holds array items selected by index, keyed by position, so
+// selections of different items of one array merge without losing any.
+// compactSelection turns them into a list in index order.
+type indexedItems map[int]interface{}
+
+// projection selects parts of a response body
+type projection struct {
+	paths   [][]pathSegment
+	limit   int
+	limited bool
+}
+
+// hasReservedArguments reports whether the reserved projection arguments can
+// be offered for an operation, that is, no parameter already uses the names
+func hasReservedArguments(op *parser.OperationInfo) bool {
+	for _, param := range op.Operation.Parameters {
+		if param.Name == fieldsArgument || param.Name == limitArgument {
+			return false
+		}
+	}
+	return true
+}
+
+// projectionProperties describes the reserved projection arguments
+func projectionProperties() map[string]Property {
+	return map[string]Property{
+		fieldsArgument: {
+			Type:        "array",
+			Description: "Return only these fields of the response body, e.g. [\"data[*].id\", \"data[*].name\", \"total\"]. Dots select object keys, [*] (or *) every array item or object value and [n] one item; keys applied to an array select them from each item.",
+			Items:       &Property{Type: "string"},
+		},
+		limitArgument: {
+			Type:        "integer",
+			Description: "Return at most this many items of the response body if it is an array, or of each array at its top level",
+		},
+	}
+}
+
+// projectionSchemas returns the validation schemas of the reserved arguments
+func projectionSchemas() map[string]*parser.Schema {
+	return map[string]*parser.Schema{
+		fieldsArgument: {Type: "array", Items: &parser.Schema{Type: "string"}},
+		limitArgument:  {Type: "integer"},
+	}
+}
+
+// parseProjection reads the reserved projection arguments. It returns nil if
+// neither is set. The arguments are ignored when building the request.
+func parseProjection(op *parser.OperationInfo, arguments map[string]interface{}) (*projection, error) {
+	if !hasReservedArguments(op) {
+		return nil, nil
+	}
+
+	fields, hasFields := arguments[fieldsArgument]
+	limit, hasLimit := arguments[limitArgument]
+	if !hasFields && !hasLimit {
+		return nil, nil
+	}
+
+	p := &projection{}
+	if hasLimit {
+		n, ok := limit.(float64)
+		if !ok || n < 0 || n != float64(int(n)) {
+			return nil, fmt.Errorf("%s must be a non-negative integer", limitArgument)
+		}
+		p.limit = int(n)
+		p.limited = true
+	}
+
+	list, _ := fields.([]interface{})
+	for _, field := range list {
+		selector, _ := field.(string)
+		path, err := parseFieldPath(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s selector %q: %w", fieldsArgument, selector, err)
+		}
+		p.paths = append(p.paths, path)
+	}
+
+	return p, nil
+}
+
+// parseFieldPath parses a field path such as $.data[*].name, items[0].id or
+// data.*.id. It is the path syntax of _fields and of completion lookups.
+func parseFieldPath(selector string) ([]pathSegment, error) {
+	selector = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(selector), "$"), ".")
+	if selector == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	var path []pathSegment
+	for _, part := range strings.Split(selector, ".") {
+		key, rest, bracket := strings.Cut(part, "[")
+		if bracket && rest == "" {
+			return nil, fmt.Errorf("missing ]")
+		}
+		if key == "*" {
+			path = append(path, pathSegment{all: true})
+		} else if key != "" {
+			path = append(path, pathSegment{key: key})
+		} else if rest == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+
+		for rest != "" {
+			inner, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("missing ]")
+			}
+			if inner == "*" {
+				path = append(path, pathSegment{all: true})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index [%s]", inner)
+				}
+				path = append(path, pathSegment{index: index})
+			}
+
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("unexpected %q after ]", after)
+			}
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+
+	return path, nil
+}
+
+// apply limits arrays and selects fields of a body. It returns the result
+// and notes on arrays that were shortened.
+func (p *projection) apply(body interface{}) (interface{}, []string) {
+	var notes []string
+	if p.limited {
+		limit := p.limit
+		switch v := body.(type) {
+		case []interface{}:
+			if len(v) > limit {
+				notes = append(notes, fmt.Sprintf("[%s: showing %d of %d items.]", limitArgument, limit, len(v)))
+				body = v[:limit]
+			}
+		case map[string]interface{}:
+			object := make(map[string]interface{}, len(v))
+			for _, key := range sortedKeys(v) {
+				object[key] = v[key]
+				if array, ok := v[key].([]interface{}); ok && len(array) > limit {
+					notes = append(notes, fmt.Sprintf("[%s: showing %d of %d items of %s.]", limitArgument, limit, len(array), key))
+					object[key] = array[:limit]
+				}
+			}
+			body = object
+		}
+	}
+
+	if len(p.paths) == 0 {
+		return body, notes
+	}
+
+	var result interface{}
+	matched := false
+	for _, path := range p.paths {
+		if value, ok := selectPath(body, path); ok {
+			result = mergeSelections(result, value)
+			matched = true
+		}
+	}
+	if !matched {
+		notes = append(notes, fmt.Sprintf("[%s: none of the selectors matched the response body.]", fieldsArgument))
+	}
+	return compactSelection(result), notes
+}
+
+// selectPath returns the parts of value selected by path, keeping the
+// enclosing objects and arrays
+func selectPath(value interface{}, path []pathSegment) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	segment := path[0]
+
+	if array, ok := value.([]interface{}); ok {
+		if segment.all {
+			return selectEach(array, path[1:])
+		}
+		index, ok := segment.arrayIndex()
+		if !ok {
+			// A key applied to an array selects it from every item
+			return selectEach(array, path)
+		}
+		if index >= len(array) {
+			return nil, false
+		}
+		item, ok := selectPath(array[index], path[1:])
+		if !ok {
+			return nil, false
+		}
+		return indexedItems{index: item}, true
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if segment.all {
+		selected := make(map[string]interface{})
+		for key, child := range object {
+			if value, ok := selectPath(child, path[1:]); ok {
+				selected[key] = value
+			}
+		}
+		return selected, len(selected) > 0
+	}
+	child, ok := object[segment.key]
+	if segment.key == "" || !ok {
+		return nil, false
+	}
+	selected, ok := selectPath(child, path[1:])
+	if !ok {
+		return nil, false
+	}
+	return map[string]interface{}{segment.key: selected}, true
+}
+
+// selectEach applies path to every item of an array, keeping one entry per
+// item so selections from the same array can be merged. Object items without
+// a match become empty objects. It matches if any item does.
+func selectEach(array []interface{}, path []pathSegment) (interface{}, bool) {
+	result := make([]interface{}, len(array))
+	matched := false
+	for i, item := range array {
+		selected, ok := selectPath(item, path)
+		if !ok {
+			if _, isObject := item.(map[string]interface{}); isObject {
+				selected = map[string]interface{}{}
+			}
+		}
+		result[i] = selected
+		matched = matched || ok
+	}
+	return result, matched
+}
+
+// mergeSelections combines two selections from the same body
+func mergeSelections(a, b interface{}) interface{} {
+	switch av := a.(type) {
+	case nil:
+		return b
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return a
+		}
+		merged := make(map[string]interface{}, len(av)+len(bv))
+		for key, value := range av {
+			merged[key] = value
+		}
+		for key, value := range bv {
+			merged[key] = mergeSelections(merged[key], value)
+		}
+		return merged
+	case []interface{}:
+		switch bv := b.(type) {
+		case []interface{}:
+			if len(bv) != len(av) {
+				return a
+			}
+			merged := make([]interface{}, len(av))
+			for i := range av {
+				merged[i] = mergeSelections(av[i], bv[i])
+			}
+			return merged
+		case indexedItems:
+			merged := append([]interface{}{}, av...)
+			for i, value := range bv {
+				if i < len(merged) {
+					merged[i] = mergeSelections(merged[i], value)
+				}
+			}
+			return merged
+		}
+		return a
+	case indexedItems:
+		switch bv := b.(type) {
+		case []interface{}:
+			return mergeSelections(bv, av)
+		case indexedItems:
+			merged := make(indexedItems, len(av)+len(bv))
+			for i, value := range av {
+				merged[i] = value
+			}
+			for i, value := range bv {
+				merged[i] = mergeSelections(merged[i], value)
+			}
+			return merged
+		}
+		return a
+	default:
+		return a
+	}
+}
+
+// compactSelection turns the index-addressed items of a selection into lists
+// in index order
+func compactSelection(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = compactSelection(child)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = compactSelection(item)
+		}
+		return v
+	case indexedItems:
+		indices := make([]int, 0, len(v))
+		for index := range v {
+			indices = append(indices, index)
+		}
+		sort.Ints(indices)
+		items := make([]interface{}, len(indices))
+		for i, index := range indices {
+			items[i] = compactSelection(v[index])
+		}
+		return items
+	default:
+		return value
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []pathSegment
+		wantErr  bool
+	}{
+		{selector: "total", want: []pathSegment{{key: "total"}}},
+		{selector: "$.data[*].name", want: []pathSegment{{key: "data"}, {all: true}, {key: "name"}}},
+		{selector: "data.*.id", want: []pathSegment{{key: "data"}, {all: true}, {key: "id"}}},
+		{selector: "items[0].id", want: []pathSegment{{key: "items"}, {index: 0}, {key: "id"}}},
+		{selector: "matrix[1][2]", want: []pathSegment{{key: "matrix"}, {index: 1}, {index: 2}}},
+		{selector: "[3]", want: []pathSegment{{index: 3}}},
+		{selector: " .a.b ", want: []pathSegment{{key: "a"}, {key: "b"}}},
+		{selector: "", wantErr: true},
+		{selector: "$", wantErr: true},
+		{selector: "a..b", wantErr: true},
+		{selector: "a[", wantErr: true},
+		{selector: "a[1", wantErr: true},
+		{selector: "a[-1]", wantErr: true},
+		{selector: "a[x]", wantErr: true},
+		{selector: "a[1]b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := parseFieldPath(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldPath(%q) error = %v, want error %v", tt.selector, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldPath(%q) = %+v, want %+v", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProjection(t *testing.T) {
+	op := &parser.OperationInfo{Operation: &parser.Operation{}}
+	shadowed := &parser.OperationInfo{Operation: &parser.Operation{
+		Parameters: []parser.Parameter{{Name: limitArgument, In: "query"}},
+	}}
+
+	tests := []struct {
+		name      string
+		op        *parser.OperationInfo
+		arguments map[string]interface{}
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "no reserved arguments", op: op, arguments: map[string]interface{}{"id": 1.0}, wantNil: true},
+		{name: "fields", op: op, arguments: map[string]interface{}{fieldsArgument: []interface{}{"a.b"}}},
+		{name: "limit", op: op, arguments: map[string]interface{}{limitArgument: 0.0}},
+		{name: "negative limit", op: op, arguments: map[string]interface{}{limitArgument: -1.0}, wantErr: true},
+		{name: "fractional limit", op: op, arguments: map[string]interface{}{limitArgument: 1.5}, wantErr: true},
+		{name: "invalid selector", op: op, arguments: map[string]interface{}{fieldsArgument: []interface{}{"a["}}, wantErr: true},
+		{name: "names taken by parameters", op: shadowed, arguments: map[string]interface{}{limitArgument: 5.0}, wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parseProjection(tt.op, tt.arguments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProjection() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (p == nil) != tt.wantNil {
+				t.Errorf("parseProjection() = %+v, want nil %v", p, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestProjectionApply(t *testing.T) {
+	const body = `{
+		"data": [
+			{"id": 1, "name": "a", "tags": ["x", "y"]},
+			{"id": 2, "name": "b", "tags": ["z"]},
+			{"id": 3, "name": "c"}
+		],
+		"total": 3,
+		"meta": {"page": 1, "next": "p2"}
+	}`
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		body      string
+		want      string
+		wantNotes []string
+	}{
+		{
+			name:      "every item",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"data[*].id"}},
+			want:      `{"data": [{"id": 1}, {"id": 2}, {"id": 3}]}`,
+		},
+		{
+			name:      "keys of an array select from each item",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"data.name"}},
+			want:      `{"data": [{"name": "a"}, {"name": "b"}, {"name": "c"}]}`,
+		},
+		{
+			name:      "several fields merge per item",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"data[*].id", "data[*].name", "total"}},
+			want:      `{"data": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}], "total": 3}`,
+		},
+		{
+			name:      "items by index",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"data[2].name", "data.0.id"}},
+			want:      `{"data": [{"id": 1}, {"name": "c"}]}`,
+		},
+		{
+			name:      "nested arrays",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"data[*].tags[0]"}},
+			want:      `{"data": [{"tags": ["x"]}, {"tags": ["z"]}, {}]}`,
+		},
+		{
+			name:      "object values",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"meta.*"}},
+			want:      `{"meta": {"page": 1, "next": "p2"}}`,
+		},
+		{
+			name:      "no match",
+			arguments: map[string]interface{}{fieldsArgument: []interface{}{"missing", "data[9]"}},
+			want:      `null`,
+			wantNotes: []string{"[_fields: none of the selectors matched the response body.]"},
+		},
+		{
+			name:      "limit arrays of an object",
+			arguments: map[string]interface{}{limitArgument: 2.0},
+			want:      `{"data": [{"id": 1, "name": "a", "tags": ["x", "y"]}, {"id": 2, "name": "b", "tags": ["z"]}], "total": 3, "meta": {"page": 1, "next": "p2"}}`,
+			wantNotes: []string{"[_limit: showing 2 of 3 items of data.]"},
+		},
+		{
+			name:      "limit an array",
+			arguments: map[string]interface{}{limitArgument: 1.0},
+			body:      `[1, 2, 3]`,
+			want:      `[1]`,
+			wantNotes: []string{"[_limit: showing 1 of 3 items.]"},
+		},
+		{
+			name:      "limit within bounds",
+			arguments: map[string]interface{}{limitArgument: 3.0},
+			body:      `[1, 2, 3]`,
+			want:      `[1, 2, 3]`,
+		},
+		{
+			name:      "limit then select",
+			arguments: map[string]interface{}{limitArgument: 1.0, fieldsArgument: []interface{}{"data[*].id", "total"}},
+			want:      `{"data": [{"id": 1}], "total": 3}`,
+			wantNotes: []string{"[_limit: showing 1 of 3 items of data.]"},
+		},
+	}
+
+	op := &parser.OperationInfo{Operation: &parser.Operation{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parseProjection(op, tt.arguments)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			source := tt.body
+			if source == "" {
+				source = body
+			}
+			var value, want interface{}
+			if err := json.Unmarshal([]byte(source), &value); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+
+			got, notes := p.apply(value)
+			if !reflect.DeepEqual(got, want) {
+				data, _ := json.Marshal(got)
+				t.Errorf("apply() = %s, want %s", data, tt.want)
+			}
+			if !reflect.DeepEqual(notes, tt.wantNotes) {
+				t.Errorf("notes = %q, want %q", notes, tt.wantNotes)
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// Names of the generated prompts
+const (
+	exploreAPIPrompt    = "explore_api"
+	exploreTagPrefix    = "explore_"
+	callOperationPrompt = "call_operation"
+)
+
+// Prompt represents an MCP prompt
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument represents an argument of an MCP prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage represents a message returned by prompts/get
+type PromptMessage struct {
+	Role    string                 `json:"role"`
+	Content map[string]interface{} `json:"content"`
+}
+
+// promptOperation is the view of an operation available to prompt templates
+type promptOperation struct {
+	ID          string
+	Tool        string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	URI         string
+}
+
+// validatePrompts checks that user-defined prompts reference known operations
+// and parses their templates
+func (s *Server) validatePrompts() error {
+	s.promptTemplates = make(map[string]*template.Template, len(s.config.Prompts))
+	for _, prompt := range s.config.Prompts {
+		for _, operationID := range prompt.Operations {
+			if s.findOperation(operationID) == nil {
+				return fmt.Errorf("prompt %s references unknown operation %s", prompt.Name, operationID)
+			}
+		}
+
+		tmpl, err := template.New(prompt.Name).Funcs(template.FuncMap{
+			"operation": s.templateOperation,
+		}).Parse(prompt.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template for prompt %s: %w", prompt.Name, err)
+		}
+		s.promptTemplates[prompt.Name] = tmpl
+	}
+	return nil
+}
+
+// templateOperation is the operation function of prompt templates
+func (s *Server) templateOperation(operationID string) (promptOperation, error) {
+	op := s.findOperation(operationID)
+	if op == nil {
+		return promptOperation{}, fmt.Errorf("unknown operation: %s", operationID)
+	}
+	return s.promptOperation(op), nil
+}
+
+// prompts returns the generated prompts followed by user-defined ones,
+// which replace generated prompts of the same name
+func (s *Server) prompts() []Prompt {
+	custom := make(map[string]bool, len(s.config.Prompts))
+	for _, prompt := range s.config.Prompts {
+		custom[prompt.Name] = true
+	}
+
+	var prompts []Prompt
+	add := func(prompt Prompt) {
+		if !custom[prompt.Name] {
+			prompts = append(prompts, prompt)
+		}
+	}
+
+	add(Prompt{
+		Name:        exploreAPIPrompt,
+		Description: "Explore the " + apiName(s.spec.Info.Title),
+	})
+
+	descriptions := s.tagDescriptions()
+	tagPrompts := s.tagPrompts()
+	for _, tag := range s.tagNames() {
+		description := "Explore the " + apiName(tag)
+		if descriptions[tag] != "" {
+			description += ": " + descriptions[tag]
+		}
+		add(Prompt{
+			Name:        tagPrompts[tag],
+			Description: description,
+		})
+	}
+
+	add(Prompt{
+		Name:        callOperationPrompt,
+		Description: "Call an operation step by step",
+		Arguments: []PromptArgument{
+			{Name: "operation_id", Description: "The operationId to call", Required: true},
+			{Name: "goal", Description: "What the call should achieve"},
+		},
+	})
+
+	for _, prompt := range s.config.Prompts {
+		p := Prompt{
+			Name:        prompt.Name,
+			Description: prompt.Description,
+		}
+		for _, arg := range prompt.Arguments {
+			p.Arguments = append(p.Arguments, PromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+		prompts = append(prompts, p)
+	}
+
+	return prompts
+}
+
+// handlePromptsList handles prompts/list requests
+func (s *Server) handlePromptsList(request *MCPRequest) *MCPResponse {
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"prompts": s.prompts(),
+		},
+	}
+}
+
+// handlePromptsGet handles prompts/get requests
+func (s *Server) handlePromptsGet(request *MCPRequest) *MCPResponse {
+	params, _ := request.Params.(map[string]interface{})
+	name, _ := params["name"].(string)
+
+	arguments := make(map[string]string)
+	if args, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range args {
+			arguments[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	var prompt *Prompt
+	for _, p := range s.prompts() {
+		if p.Name == name {
+			prompt = &p
+			break
+		}
+	}
+	if prompt == nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Prompt not found: %s", name),
+			},
+		}
+	}
+
+	for _, arg := range prompt.Arguments {
+		if arg.Required && arguments[arg.Name] == "" {
+			return &MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: fmt.Sprintf("Missing required argument: %s", arg.Name),
+				},
+			}
+		}
+	}
+
+	messages, err := s.renderPrompt(name, arguments)
+	if err != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: err.Error(),
+			},
+		}
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"description": prompt.Description,
+			"messages":    messages,
+		},
+	}
+}
+
+// renderPrompt builds the messages of a prompt
+func (s *Server) renderPrompt(name string, arguments map[string]string) ([]PromptMessage, error) {
+	for _, prompt := range s.config.Prompts {
+		if prompt.Name == name {
+			return s.renderCustomPrompt(prompt, arguments)
+		}
+	}
+
+	switch {
+	case name == exploreAPIPrompt:
+		return []PromptMessage{textMessage(s.exploreAPIText())}, nil
+
+	case name == callOperationPrompt:
+		op := s.findOperation(arguments["operation_id"])
+		if op == nil {
+			return nil, fmt.Errorf("unknown operation: %s", arguments["operation_id"])
+		}
+		return []PromptMessage{
+			textMessage(s.callOperationText(op, arguments["goal"])),
+			s.operationResourceMessage(op),
+		}, nil
+
+	}
+
+	for tag, prompt := range s.tagPrompts() {
+		if prompt != name {
+			continue
+		}
+		overview, ok := s.renderTagOverview(tag)
+		if !ok {
+			return nil, fmt.Errorf("unknown tag: %s", tag)
+		}
+		text := fmt.Sprintf("I want to explore the %s. Here is an overview of its operations:\n\n%s\n"+
+			"Explain what these operations can be used for and how they relate to each other. "+
+			"Read %s{operationId} for the full documentation of an operation before calling it.",
+			apiName(tag), overview, operationResourcePrefix)
+		return []PromptMessage{textMessage(text)}, nil
+	}
+
+	return nil, fmt.Errorf("prompt not found: %s", name)
+}
+
+// renderCustomPrompt executes a user-defined prompt template. Operations
+// listed by the prompt are attached as embedded documentation resources.
+func (s *Server) renderCustomPrompt(prompt config.Prompt, arguments map[string]string) ([]PromptMessage, error) {
+	var operations []promptOperation
+	for _, operationID := range prompt.Operations {
+		if op := s.findOperation(operationID); op != nil {
+			operations = append(operations, s.promptOperation(op))
+		}
+	}
+
+	var b strings.Builder
+	err := s.promptTemplates[prompt.Name].Execute(&b, map[string]interface{}{
+		"Args":       arguments,
+		"Operations": operations,
+		"Info":       s.spec.Info,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	messages := []PromptMessage{textMessage(b.String())}
+	for _, operationID := range prompt.Operations {
+		if op := s.findOperation(operationID); op != nil {
+			messages = append(messages, s.operationResourceMessage(op))
+		}
+	}
+
+	return messages, nil
+}
+
+// tagPrompts maps each tag in use to the name of its explore prompt. Names
+// are the tag reduced to letters, digits, '_' and '-', numbered when they
+// collide with another prompt.
+func (s *Server) tagPrompts() map[string]string {
+	taken := map[string]bool{exploreAPIPrompt: true, callOperationPrompt: true}
+	prompts := make(map[string]string)
+
+	for _, tag := range s.tagNames() {
+		base := exploreTagPrefix + promptNamePart(tag)
+		name := base
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		taken[name] = true
+		prompts[tag] = name
+	}
+	return prompts
+}
+
+// promptNamePart replaces each run of characters other than ASCII letters,
+// digits, '_' and '-' with a single '_'
+func promptNamePart(tag string) string {
+	var b strings.Builder
+	replaced := false
+	for _, r := range tag {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			b.WriteRune(r)
+			replaced = false
+		} else if !replaced {
+			b.WriteByte('_')
+			replaced = true
+		}
+	}
+
+	part := strings.Trim(b.String(), "_")
+	if part == "" {
+		return "tag"
+	}
+	return part
+}
+
+// apiName names an API by its title or tag, adding "API" unless the name
+// already ends with it
+func apiName(name string) string {
+	fields := strings.Fields(name)
+	if len(fields) > 0 && strings.EqualFold(fields[len(fields)-1], "API") {
+		return name
+	}
+	return name + " API"
+}
+
+// exploreAPIText describes the whole API for the explore_api prompt
+func (s *Server) exploreAPIText() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "I want to explore the %s (version %s).\n\n", apiName(s.spec.Info.Title), s.spec.Info.Version)
+	if s.spec.Info.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", s.spec.Info.Description)
+	}
+
+	if tags := s.tagNames(); len(tags) > 0 {
+		descriptions := s.tagDescriptions()
+		b.WriteString("It is organized into these areas:\n\n")
+		for _, tag := range tags {
+			fmt.Fprintf(&b, "- %s (%d operations)", tag, len(s.taggedOperations(tag)))
+			if descriptions[tag] != "" {
+				fmt.Fprintf(&b, ": %s", descriptions[tag])
+			}
+			fmt.Fprintf(&b, " - see %s%s\n", tagResourcePrefix, url.PathEscape(tag))
+		}
+		b.WriteString("\n")
+	} else {
+		fmt.Fprintf(&b, "It provides %d operations.\n\n", len(s.catalog().tools))
+	}
+
+	b.WriteString("Summarize what the API can do, which areas are most useful, and suggest a few first calls to try.")
+	return b.String()
+}
+
+// callOperationText guides the model through calling one operation
+func (s *Server) callOperationText(op *parser.OperationInfo, goal string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "I want to call the %s operation (`%s %s`)", op.OperationID, op.Method, op.Path)
+	if op.Operation.Summary != "" {
+		fmt.Fprintf(&b, ": %s", op.Operation.Summary)
+	}
+	b.WriteString(".\n\n")
+	if goal != "" {
+		fmt.Fprintf(&b, "Goal: %s\n\n", goal)
+	}
+
+	call := fmt.Sprintf("Call the %s tool with those arguments.", s.generateToolName(*op))
+	if s.config.Tools.Mode == config.ToolsModeDiscovery {
+		call = fmt.Sprintf("Call the %s tool with operation_id %q and those arguments.", invokeOperationTool, op.OperationID)
+	}
+
+	fmt.Fprintf(&b, "Work through it step by step:\n\n"+
+		"1. Read the attached documentation and identify the required parameters and request body.\n"+
+		"2. Collect every required value, asking me for anything you cannot determine.\n"+
+		"3. %s\n"+
+		"4. Check the status code and explain the response, including any error details.\n",
+		call)
+
+	return b.String()
+}
+
+// promptOperation builds the template view of an operation
+func (s *Server) promptOperation(op *parser.OperationInfo) promptOperation {
+	return promptOperation{
+		ID:          op.OperationID,
+		Tool:        s.generateToolName(*op),
+		Method:      op.Method,
+		Path:        op.Path,
+		Summary:     op.Operation.Summary,
+		Description: op.Operation.Description,
+		URI:         operationResourcePrefix + url.PathEscape(op.OperationID),
+	}
+}
+
+// operationResourceMessage embeds the documentation of an operation
+func (s *Server) operationResourceMessage(op *parser.OperationInfo) PromptMessage {
+	return PromptMessage{
+		Role: "user",
+		Content: map[string]interface{}{
+			"type": "resource",
+			"resource": ResourceContents{
+				URI:      operationResourcePrefix + url.PathEscape(op.OperationID),
+				MimeType: "text/markdown",
+				Text:     s.renderOperationDoc(op),
+			},
+		},
+	}
+}
+
+// textMessage builds a user text message
+func textMessage(text string) PromptMessage {
+	return PromptMessage{
+		Role: "user",
+		Content: map[string]interface{}{
+			"type": "text",
+			"text": text,
+		},
+	}
+}
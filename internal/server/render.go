@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// responseView is the data a tool result is rendered from. Templates see
+// it as .StatusCode, .Headers, .Body and .Operation.
+type responseView struct {
+	StatusCode int
+	Headers    map[string]interface{}
+	Body       interface{}
+	Operation  string
+}
+
+// rendering is how the result of one operation is rendered
+type rendering struct {
+	format   string
+	headers  []string
+	template *template.Template
+}
+
+// templateFuncs are the helpers available to response templates
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) string {
+		data, _ := json.Marshal(value)
+		return string(data)
+	},
+	"yaml": func(value interface{}) string {
+		return strings.TrimRight(marshalYAML(value), "\n")
+	},
+	"table": markdownBody,
+}
+
+// parseResponseTemplates parses the response templates of all operations
+func parseResponseTemplates(cfg config.Responses) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+	for _, response := range cfg.Operations {
+		if response.Template == "" {
+			continue
+		}
+		tmpl, err := template.New(response.Operation).Funcs(templateFuncs).Parse(response.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response template for %s: %w", response.Operation, err)
+		}
+		templates[response.Operation] = tmpl
+	}
+	return templates, nil
+}
+
+// rendering returns the rendering of an operation: the global format and
+// header allowlist, overridden by the operation's settings
+func (s *Server) rendering(operationID string) rendering {
+	r := rendering{
+		format:   s.config.Responses.Format,
+		headers:  s.config.Responses.Headers,
+		template: s.templates[operationID],
+	}
+	for _, response := range s.config.Responses.Operations {
+		if response.Operation != operationID {
+			continue
+		}
+		if response.Format != "" {
+			r.format = response.Format
+		}
+		if len(response.Headers) > 0 {
+			r.headers = response.Headers
+		}
+	}
+	return r
+}
+
+// renderResponse renders the text of a successful tool result
+func (s *Server) renderResponse(ctx context.Context, operationID string, statusCode int, headers http.Header, body interface{}) string {
+	r := s.rendering(operationID)
+	view := responseView{
+		StatusCode: statusCode,
+		Headers:    headerValues(allowedHeaders(headers, r.headers)),
+		Body:       body,
+		Operation:  operationID,
+	}
+
+	if r.template != nil {
+		var b strings.Builder
+		err := r.template.Execute(&b, view)
+		if err == nil {
+			return b.String()
+		}
+		logger.Warn("Failed to render response template, falling back to the default format",
+			logger.Session(ctx),
+			zap.String("operation", operationID),
+			zap.Error(err))
+	}
+
+	envelope := map[string]interface{}{
+		"status_code": view.StatusCode,
+		"body":        view.Body,
+	}
+	if len(view.Headers) > 0 {
+		envelope["headers"] = view.Headers
+	}
+
+	switch r.format {
+	case config.ResponseFormatCompactJSON:
+		data, _ := json.Marshal(envelope)
+		return string(data)
+	case config.ResponseFormatYAML:
+		return marshalYAML(envelope)
+	case config.ResponseFormatMarkdown:
+		return renderMarkdown(view)
+	}
+
+	data, _ := json.MarshalIndent(envelope, "", "  ")
+	return string(data)
+}
+
+// allowedHeaders returns the headers on an allowlist. An empty allowlist
+// allows every header and "none" allows none.
+func allowedHeaders(headers http.Header, allowlist []string) http.Header {
+	if len(allowlist) == 0 {
+		return headers
+	}
+
+	allowed := make(http.Header)
+	for _, name := range allowlist {
+		if strings.EqualFold(name, config.HeadersNone) {
+			continue
+		}
+		if values := headers.Values(name); len(values) > 0 {
+			allowed[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return allowed
+}
+
+// renderMarkdown renders a response as Markdown, with arrays of flat objects
+// as tables
+func renderMarkdown(view responseView) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "**Status:** %d %s\n", view.StatusCode, http.StatusText(view.StatusCode))
+	if len(view.Headers) > 0 {
+		b.WriteString("\n**Headers:**\n")
+		for _, name := range sortedKeys(view.Headers) {
+			fmt.Fprintf(&b, "- %s: %s\n", name, markdownValue(view.Headers[name]))
+		}
+	}
+
+	if view.Body != nil {
+		b.WriteString("\n")
+		b.WriteString(markdownBody(view.Body))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// marshalYAML encodes a value as YAML with two-space indentation
+func marshalYAML(value interface{}) string {
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	encoder.Close()
+	return b.String()
+}
+
+// markdownBody renders a body as a table, a list of fields or a JSON block
+func markdownBody(body interface{}) string {
+	if rows, ok := flatRows(body); ok {
+		return markdownTable(rows)
+	}
+
+	switch v := body.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		var b strings.Builder
+		for _, key := range sortedKeys(v) {
+			if rows, ok := flatRows(v[key]); ok {
+				fmt.Fprintf(&b, "\n**%s:**\n\n%s\n", key, markdownTable(rows))
+				continue
+			}
+			fmt.Fprintf(&b, "- **%s:** %s\n", key, markdownValue(v[key]))
+		}
+		return strings.TrimSpace(b.String())
+	}
+
+	data, _ := json.MarshalIndent(body, "", "  ")
+	return "```json\n" + string(data) + "\n```"
+}
+
+// markdownValue formats a value inline: scalars as text, anything else as
+// compact JSON in backquotes
+func markdownValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}, []string:
+		data, _ := json.Marshal(v)
+		return "`" + string(data) + "`"
+	default:
+		return formatScalar(v)
+	}
+}
+
+// flatRows returns a value as table rows if it is a non-empty array of
+// objects whose fields are all scalars
+func flatRows(value interface{}) ([]map[string]interface{}, bool) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, false
+	}
+
+	rows := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for _, field := range row {
+			switch field.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, false
+			}
+		}
+		rows[i] = row
+	}
+	return rows, true
+}
+
+// markdownTable renders rows as a Markdown table. Columns are the fields of
+// the first row followed by fields that only appear in later rows.
+func markdownTable(rows []map[string]interface{}) string {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		var added []string
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				added = append(added, name)
+			}
+		}
+		sort.Strings(added)
+		columns = append(columns, added...)
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(escapeCells(columns), " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, name := range columns {
+			cells[i] = formatScalar(row[name])
+		}
+		b.WriteString("| " + strings.Join(escapeCells(cells), " | ") + " |\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// escapeCells escapes pipes and line breaks in table cells
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		cell = strings.ReplaceAll(cell, "\r\n", "<br>")
+		escaped[i] = strings.ReplaceAll(cell, "\n", "<br>")
+	}
+	return escaped
+}
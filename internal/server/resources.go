@@ -0,0 +1,540 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Resource URIs exposed by the server
+const (
+	specResourceURI         = "openapi://spec"
+	tagResourcePrefix       = "openapi://tags/"
+	operationResourcePrefix = "openapi://operations/"
+)
+
+// Resource represents an MCP resource
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate represents an MCP resource template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents represents the contents of a resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// handleResourcesList handles resources/list requests
+func (s *Server) handleResourcesList(request *MCPRequest) *MCPResponse {
+	resources := []Resource{
+		{
+			URI:         specResourceURI,
+			Name:        "OpenAPI specification",
+			Description: fmt.Sprintf("The OpenAPI document for %s %s", s.spec.Info.Title, s.spec.Info.Version),
+			MimeType:    s.specMimeType(),
+		},
+	}
+
+	descriptions := s.tagDescriptions()
+	for _, tag := range s.tagNames() {
+		description := descriptions[tag]
+		if description == "" {
+			description = fmt.Sprintf("Overview of the operations tagged %s", tag)
+		}
+		resources = append(resources, Resource{
+			URI:         tagResourcePrefix + url.PathEscape(tag),
+			Name:        fmt.Sprintf("%s API", tag),
+			Description: description,
+			MimeType:    "text/markdown",
+		})
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"resources": resources,
+		},
+	}
+}
+
+// handleResourceTemplatesList handles resources/templates/list requests
+func (s *Server) handleResourceTemplatesList(request *MCPRequest) *MCPResponse {
+	templates := []ResourceTemplate{
+		{
+			URITemplate: operationResourcePrefix + "{operationId}",
+			Name:        "Operation documentation",
+			Description: "Parameters, request body, responses, schemas and examples of one operation",
+			MimeType:    "text/markdown",
+		},
+		{
+			URITemplate: tagResourcePrefix + "{tag}",
+			Name:        "Tag overview",
+			Description: "Description and operations of one tag",
+			MimeType:    "text/markdown",
+		},
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"resourceTemplates": templates,
+		},
+	}
+}
+
+// handleResourcesRead handles resources/read requests
+func (s *Server) handleResourcesRead(request *MCPRequest) *MCPResponse {
+	params, _ := request.Params.(map[string]interface{})
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Missing resource uri",
+			},
+		}
+	}
+
+	contents, err := s.readResource(uri)
+	if err != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32002,
+				Message: err.Error(),
+				Data:    map[string]interface{}{"uri": uri},
+			},
+		}
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"contents": contents,
+		},
+	}
+}
+
+// readResource returns the contents of the resource identified by uri
+func (s *Server) readResource(uri string) ([]ResourceContents, error) {
+	switch {
+	case uri == specResourceURI:
+		text, err := s.exposedSpec()
+		if err != nil {
+			return nil, err
+		}
+		return []ResourceContents{{
+			URI:      uri,
+			MimeType: s.specMimeType(),
+			Text:     text,
+		}}, nil
+
+	case strings.HasPrefix(uri, tagResourcePrefix):
+		tag, err := url.PathUnescape(strings.TrimPrefix(uri, tagResourcePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag in resource uri: %s", uri)
+		}
+		text, ok := s.renderTagOverview(tag)
+		if !ok {
+			return nil, fmt.Errorf("resource not found: %s", uri)
+		}
+		return []ResourceContents{{URI: uri, MimeType: "text/markdown", Text: text}}, nil
+
+	case strings.HasPrefix(uri, responseResourcePrefix):
+		return s.readStoredResponse(uri)
+
+	case strings.HasPrefix(uri, operationResourcePrefix):
+		operationID, err := url.PathUnescape(strings.TrimPrefix(uri, operationResourcePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation id in resource uri: %s", uri)
+		}
+		op := s.findOperation(operationID)
+		if op == nil {
+			return nil, fmt.Errorf("resource not found: %s", uri)
+		}
+		return []ResourceContents{{URI: uri, MimeType: "text/markdown", Text: s.renderOperationDoc(op)}}, nil
+
+	default:
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+}
+
+// exposedSpec returns the specification served as openapi://spec. Operations
+// hidden by the tool filters are pruned, along with paths left without any
+// operation, so the document only describes what clients can call.
+func (s *Server) exposedSpec() (string, error) {
+	exposed := make(map[string]bool)
+	for _, tool := range s.catalog().tools {
+		exposed[strings.ToLower(tool.Operation.Method)+" "+tool.Operation.Path] = true
+	}
+	if len(exposed) == len(s.parser.GetOperations(s.spec)) {
+		return string(s.spec.Raw), nil
+	}
+
+	// YAML nodes keep the order and comments of the document; JSON parses as YAML
+	var root yaml.Node
+	if err := yaml.Unmarshal(s.spec.Raw, &root); err != nil {
+		return "", fmt.Errorf("failed to parse the specification: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return "", fmt.Errorf("the specification is empty")
+	}
+	if paths := mappingValue(root.Content[0], "paths"); paths != nil {
+		prunePaths(paths, exposed)
+	}
+
+	if json.Valid(s.spec.Raw) {
+		var document interface{}
+		if err := root.Decode(&document); err != nil {
+			return "", fmt.Errorf("failed to encode the specification: %w", err)
+		}
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the specification: %w", err)
+		}
+		return string(data), nil
+	}
+	return marshalYAML(&root), nil
+}
+
+// httpMethods are the operation keys of an OpenAPI path item
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// prunePaths removes the operations that are not exposed from a paths
+// object, dropping path items without operations
+func prunePaths(paths *yaml.Node, exposed map[string]bool) {
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, item := paths.Content[i], paths.Content[i+1]
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var fields []*yaml.Node
+		operations := 0
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			key := item.Content[j].Value
+			if httpMethods[strings.ToLower(key)] {
+				if !exposed[strings.ToLower(key)+" "+path.Value] {
+					continue
+				}
+				operations++
+			}
+			fields = append(fields, item.Content[j], item.Content[j+1])
+		}
+		if operations == 0 {
+			continue
+		}
+		item.Content = fields
+		kept = append(kept, path, item)
+	}
+	paths.Content = kept
+}
+
+// mappingValue returns the value of a key in a YAML mapping, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// specMimeType returns the media type of the raw specification
+func (s *Server) specMimeType() string {
+	if json.Valid(s.spec.Raw) {
+		return "application/json"
+	}
+	return "application/yaml"
+}
+
+// findOperation returns the operation with the given operationId
+func (s *Server) findOperation(operationID string) *parser.OperationInfo {
+	if tool := s.catalog().operation(operationID); tool != nil {
+		return tool.Operation
+	}
+	return nil
+}
+
+// tagNames returns the tags in use, in spec order followed by undeclared tags
+func (s *Server) tagNames() []string {
+	used := make(map[string]bool)
+	for _, tool := range s.catalog().tools {
+		for _, tag := range tool.Operation.Operation.Tags {
+			used[tag] = true
+		}
+	}
+
+	var names []string
+	for _, tag := range s.spec.Tags {
+		if used[tag.Name] {
+			names = append(names, tag.Name)
+			delete(used, tag.Name)
+		}
+	}
+
+	var extra []string
+	for tag := range used {
+		extra = append(extra, tag)
+	}
+	sort.Strings(extra)
+
+	return append(names, extra...)
+}
+
+// tagDescriptions maps tag names to their declared descriptions
+func (s *Server) tagDescriptions() map[string]string {
+	descriptions := make(map[string]string, len(s.spec.Tags))
+	for _, tag := range s.spec.Tags {
+		descriptions[tag.Name] = tag.Description
+	}
+	return descriptions
+}
+
+// taggedOperations returns the operations carrying tag, sorted by path and method
+func (s *Server) taggedOperations(tag string) []*parser.OperationInfo {
+	var operations []*parser.OperationInfo
+	for _, tool := range s.catalog().tools {
+		for _, t := range tool.Operation.Operation.Tags {
+			if t == tag {
+				operations = append(operations, tool.Operation)
+				break
+			}
+		}
+	}
+
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return operations[i].Method < operations[j].Method
+	})
+
+	return operations
+}
+
+// renderTagOverview renders the Markdown overview of a tag
+func (s *Server) renderTagOverview(tag string) (string, bool) {
+	operations := s.taggedOperations(tag)
+	if len(operations) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", tag)
+	if description := s.tagDescriptions()[tag]; description != "" {
+		fmt.Fprintf(&b, "%s\n\n", description)
+	}
+
+	b.WriteString("| Operation | Method | Path | Summary |\n")
+	b.WriteString("|---|---|---|---|\n")
+	for _, op := range operations {
+		fmt.Fprintf(&b, "| [%s](%s%s) | %s | `%s` | %s |\n",
+			op.OperationID, operationResourcePrefix, url.PathEscape(op.OperationID),
+			op.Method, op.Path, markdownCell(op.Operation.Summary))
+	}
+
+	return b.String(), true
+}
+
+// renderOperationDoc renders the Markdown documentation of an operation
+func (s *Server) renderOperationDoc(op *parser.OperationInfo) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", op.OperationID)
+	fmt.Fprintf(&b, "`%s %s`\n\n", op.Method, op.Path)
+	if op.Operation.Summary != "" {
+		fmt.Fprintf(&b, "**%s**\n\n", op.Operation.Summary)
+	}
+	if op.Operation.Description != "" && op.Operation.Description != op.Operation.Summary {
+		fmt.Fprintf(&b, "%s\n\n", op.Operation.Description)
+	}
+	if len(op.Operation.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n\n", strings.Join(op.Operation.Tags, ", "))
+	}
+
+	// Parameters
+	var bodyParam *parser.Parameter
+	var params []parser.Parameter
+	for i, param := range op.Operation.Parameters {
+		if param.In == "body" {
+			bodyParam = &op.Operation.Parameters[i]
+			continue
+		}
+		params = append(params, param)
+	}
+
+	if len(params) > 0 {
+		b.WriteString("## Parameters\n\n")
+		b.WriteString("| Name | In | Type | Required | Description |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, param := range params {
+			fmt.Fprintf(&b, "| %s | %s | %s | %t | %s |\n",
+				param.Name, param.In, s.schemaTypeName(param.EffectiveSchema()),
+				param.Required, markdownCell(param.Description))
+		}
+		b.WriteString("\n")
+
+		for _, param := range params {
+			schema := s.parser.ExpandSchema(s.spec, param.EffectiveSchema())
+			if param.Example == nil && (schema == nil || (len(schema.Enum) == 0 && schema.Default == nil && schema.Example == nil)) {
+				continue
+			}
+			fmt.Fprintf(&b, "### %s\n\n", param.Name)
+			if schema != nil && len(schema.Enum) > 0 {
+				fmt.Fprintf(&b, "Allowed values: %s\n\n", joinValues(schema.Enum))
+			}
+			if schema != nil && schema.Default != nil {
+				fmt.Fprintf(&b, "Default: `%v`\n\n", schema.Default)
+			}
+			if param.Example != nil {
+				fmt.Fprintf(&b, "Example: `%v`\n\n", param.Example)
+			} else if schema != nil && schema.Example != nil {
+				fmt.Fprintf(&b, "Example: `%v`\n\n", schema.Example)
+			}
+		}
+	}
+
+	// Request body
+	if body := op.Operation.RequestBody; body != nil {
+		b.WriteString("## Request Body\n\n")
+		if body.Required {
+			b.WriteString("Required.\n\n")
+		}
+		if body.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", body.Description)
+		}
+		for _, mediaType := range sortedKeys(body.Content) {
+			s.renderMediaType(&b, mediaType, body.Content[mediaType])
+		}
+	} else if bodyParam != nil {
+		b.WriteString("## Request Body\n\n")
+		if bodyParam.Required {
+			b.WriteString("Required.\n\n")
+		}
+		if bodyParam.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", bodyParam.Description)
+		}
+		s.renderMediaType(&b, "application/json", parser.MediaType{Schema: bodyParam.Schema, Example: bodyParam.Example})
+	}
+
+	// Responses
+	if len(op.Operation.Responses) > 0 {
+		b.WriteString("## Responses\n\n")
+		for _, status := range sortedKeys(op.Operation.Responses) {
+			response := op.Operation.Responses[status]
+			fmt.Fprintf(&b, "### %s\n\n", status)
+			if response.Description != "" {
+				fmt.Fprintf(&b, "%s\n\n", response.Description)
+			}
+			for _, mediaType := range sortedKeys(response.Content) {
+				s.renderMediaType(&b, mediaType, response.Content[mediaType])
+			}
+			if response.Schema != nil {
+				s.renderMediaType(&b, "application/json", parser.MediaType{Schema: response.Schema})
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// renderMediaType renders the expanded schema and examples of a media type
+func (s *Server) renderMediaType(b *strings.Builder, mediaType string, content parser.MediaType) {
+	fmt.Fprintf(b, "Content type: `%s`\n\n", mediaType)
+
+	if schema := s.parser.ExpandSchema(s.spec, content.Schema); schema != nil {
+		b.WriteString("Schema:\n\n")
+		writeJSONBlock(b, schema)
+	}
+	if content.Example != nil {
+		b.WriteString("Example:\n\n")
+		writeJSONBlock(b, content.Example)
+	}
+	if content.Examples != nil {
+		b.WriteString("Examples:\n\n")
+		writeJSONBlock(b, content.Examples)
+	}
+}
+
+// schemaTypeName returns a short type name for a schema
+func (s *Server) schemaTypeName(schema *parser.Schema) string {
+	schema = s.parser.ResolveSchema(s.spec, schema)
+	if schema == nil || schema.Type == "" {
+		return "any"
+	}
+	if schema.Type == "array" {
+		return "array of " + s.schemaTypeName(schema.Items)
+	}
+	if schema.Format != "" {
+		return fmt.Sprintf("%s (%s)", schema.Type, schema.Format)
+	}
+	return schema.Type
+}
+
+// writeJSONBlock writes value as an indented JSON code block
+func writeJSONBlock(b *strings.Builder, value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Fprintf(b, "```\n%v\n```\n\n", value)
+		return
+	}
+	fmt.Fprintf(b, "```json\n%s\n```\n\n", data)
+}
+
+// markdownCell makes text safe to place in a Markdown table cell
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", " ")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// joinValues formats enum values as a comma-separated list
+func joinValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("`%v`", value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,386 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+	"go.uber.org/zap"
+)
+
+// audioContentVersion is the first protocol version with audio content
+const audioContentVersion = "2025-03-26"
+
+// resourceLinkVersion is the first protocol version with resource_link content
+const resourceLinkVersion = "2025-06-18"
+
+// bytesPerToken estimates the size of a token for response budgets
+const bytesPerToken = 4
+
+// attachment is binary response content returned alongside the text result
+type attachment struct {
+	URI      string
+	MimeType string
+	Data     []byte
+}
+
+// contentBlock returns the MCP content for an attachment: image and audio
+// content where the session supports it, otherwise an embedded blob resource
+func (a attachment) contentBlock(sess *session) map[string]interface{} {
+	data := base64.StdEncoding.EncodeToString(a.Data)
+
+	switch {
+	case strings.HasPrefix(a.MimeType, "image/"):
+		return map[string]interface{}{"type": "image", "data": data, "mimeType": a.MimeType}
+	case strings.HasPrefix(a.MimeType, "audio/") && sess.supports(audioContentVersion):
+		return map[string]interface{}{"type": "audio", "data": data, "mimeType": a.MimeType}
+	default:
+		return map[string]interface{}{
+			"type": "resource",
+			"resource": ResourceContents{
+				URI:      a.URI,
+				MimeType: a.MimeType,
+				Blob:     data,
+			},
+		}
+	}
+}
+
+// resourceLink points a tool result at a resource the client can read
+type resourceLink struct {
+	URI         string
+	Name        string
+	Description string
+	MimeType    string
+	Size        int
+}
+
+// contentBlock returns the resource_link content for a link
+func (l resourceLink) contentBlock() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "resource_link",
+		"uri":         l.URI,
+		"name":        l.Name,
+		"description": l.Description,
+		"mimeType":    l.MimeType,
+		"size":        l.Size,
+	}
+}
+
+// responseBudget returns the largest tool result in bytes for an operation,
+// combining its byte and token budgets, or 0 if it is unlimited
+func (s *Server) responseBudget(operationID string) int64 {
+	maxBytes, maxTokens := s.config.Responses.MaxBytes, s.config.Responses.MaxTokens
+	for _, budget := range s.config.Responses.Operations {
+		if budget.Operation != operationID {
+			continue
+		}
+		if budget.MaxBytes > 0 {
+			maxBytes = budget.MaxBytes
+		}
+		if budget.MaxTokens > 0 {
+			maxTokens = budget.MaxTokens
+		}
+	}
+
+	if tokenBytes := int64(maxTokens) * bytesPerToken; tokenBytes > 0 && (maxBytes == 0 || tokenBytes < maxBytes) {
+		return tokenBytes
+	}
+	return maxBytes
+}
+
+// limitResult keeps a tool result within the operation's budget, counting
+// its text, notes and structured content. Oversized attachments are dropped,
+// structured content is dropped once the result is over budget and oversized
+// text is truncated with a marker; the full body is stored as a resource and
+// linked from the result.
+func (s *Server) limitResult(ctx context.Context, op *parser.OperationInfo, response *requester.Response, result *toolResult) {
+	limit := s.responseBudget(op.Operation.OperationID)
+	name := op.Operation.OperationID
+	if name == "" {
+		name = op.Method + " " + op.Path
+	}
+
+	var notes []string
+	attachments := result.Attachments[:0]
+	for _, a := range result.Attachments {
+		if limit == 0 || int64(base64.StdEncoding.EncodedLen(len(a.Data))) <= limit {
+			attachments = append(attachments, a)
+			continue
+		}
+		if entry := s.responses.put(name, a.MimeType, a.Data, false); entry != nil {
+			result.Links = append(result.Links, entry.link())
+			notes = append(notes, fmt.Sprintf("[The %s body is over this tool's %d-byte budget and is not attached. It is available as resource %s; read it with resources/read, appending ?offset=<byte>&length=<bytes> to the uri to fetch a range.]",
+				a.MimeType, limit, entry.uri))
+		} else {
+			notes = append(notes, fmt.Sprintf("[The %s body is over this tool's %d-byte budget and too large to keep, so it is not included.]", a.MimeType, limit))
+		}
+	}
+	result.Attachments = attachments
+
+	if response.Truncated {
+		notes = append(notes, fmt.Sprintf("[The upstream response exceeded %d bytes; the rest was not read.]", s.config.Responses.MaxReadBytes))
+	}
+
+	if limit > 0 && int64(len(result.Text)+notesSize(notes)+structuredSize(result.Structured)) > limit {
+		size := len(result.Text)
+		data, mimeType, text := storedBody(response)
+		entry := s.responses.put(name, mimeType, data, text)
+		if entry != nil {
+			result.Links = append(result.Links, entry.link())
+		}
+
+		// Structured content repeats the body, so it is the first to go
+		if result.Structured != nil {
+			result.Structured = nil
+			if int64(size+notesSize(notes)) <= limit {
+				notes = append(notes, structuredNote(entry, limit))
+			}
+		}
+
+		if int64(size+notesSize(notes)) > limit {
+			note := func(shown int) string {
+				if entry == nil {
+					return fmt.Sprintf("[Truncated: this result is %d bytes (about %d tokens) and only the first %d bytes are shown. The full response is too large to keep.]",
+						size, (size+bytesPerToken-1)/bytesPerToken, shown)
+				}
+				return fmt.Sprintf("[Truncated: this result is %d bytes (about %d tokens) and only the first %d bytes are shown. The full response body (%d bytes of %s) is available as resource %s; read it with resources/read, appending ?offset=<byte>&length=<bytes> to the uri to fetch a range.]",
+					size, (size+bytesPerToken-1)/bytesPerToken, shown, len(data), mimeType, entry.uri)
+			}
+
+			// Leave room for the notes, which only get shorter as fewer bytes are shown
+			shown := int(limit) - notesSize(append(notes, note(size)))
+			if shown < 0 {
+				shown = 0
+			}
+			result.Text = truncateText(result.Text, shown)
+			notes = append(notes, note(len(result.Text)))
+		}
+
+		logger.Info("Limited oversized tool result",
+			logger.Session(ctx),
+			zap.String("operation", name),
+			zap.Int("bytes", size),
+			zap.Int64("limit", limit))
+	}
+
+	if len(notes) > 0 {
+		result.Text += notesSeparator + strings.Join(notes, "\n")
+	}
+}
+
+// notesSeparator separates the notes from the text of a result
+const notesSeparator = "\n\n"
+
+// notesSize returns the bytes the notes add to a result's text
+func notesSize(notes []string) int {
+	if len(notes) == 0 {
+		return 0
+	}
+	return len(notesSeparator) + len(strings.Join(notes, "\n"))
+}
+
+// structuredSize returns the serialized size of structured content
+func structuredSize(structured interface{}) int {
+	if structured == nil {
+		return 0
+	}
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// structuredNote explains structured content dropped from a result that is
+// over budget
+func structuredNote(entry *storedResponse, limit int64) string {
+	if entry == nil {
+		return fmt.Sprintf("[Structured content is omitted because the result is over this tool's %d-byte budget.]", limit)
+	}
+	return fmt.Sprintf("[Structured content is omitted because the result is over this tool's %d-byte budget. The full response body is available as resource %s.]", limit, entry.uri)
+}
+
+// storedBody returns the full response body as kept in the response store,
+// with its media type and whether it is text
+func storedBody(response *requester.Response) ([]byte, string, bool) {
+	switch body := response.Body.(type) {
+	case []byte:
+		return body, response.MediaType, false
+	case string:
+		mimeType := response.MediaType
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+		return []byte(body), mimeType, true
+	default:
+		data, _ := json.MarshalIndent(body, "", "  ")
+		return data, "application/json", true
+	}
+}
+
+// truncateText cuts text to at most limit bytes without splitting a character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+// responseBody returns the body shown in a tool result. Binary bodies are
+// replaced by a short note and returned as an attachment; XML and CSV are
+// converted to structured data.
+func responseBody(response *requester.Response) (interface{}, []attachment) {
+	switch body := response.Body.(type) {
+	case []byte:
+		mimeType := response.MediaType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		note := fmt.Sprintf("binary %s content, %d bytes", mimeType, len(body))
+		return note, []attachment{{URI: response.URL, MimeType: mimeType, Data: body}}
+
+	case string:
+		switch {
+		case isXMLMediaType(response.MediaType):
+			if value, err := xmlToValue(body); err == nil {
+				return value, nil
+			}
+		case isCSVMediaType(response.MediaType):
+			if rows, err := csvToRows(body, response.MediaType); err == nil {
+				return rows, nil
+			}
+		}
+	}
+
+	return response.Body, nil
+}
+
+// isCSVMediaType reports whether a media type is comma or tab separated values
+func isCSVMediaType(mediaType string) bool {
+	return mediaType == "text/csv" || mediaType == "application/csv" || mediaType == "text/tab-separated-values"
+}
+
+// csvToRows converts CSV or TSV with a header row to a list of objects keyed
+// by the header fields
+func csvToRows(text, mediaType string) ([]interface{}, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	if mediaType == "text/tab-separated-values" {
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+	}
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", mediaType, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty %s", mediaType)
+	}
+
+	header := records[0]
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, field := range record {
+			name := fmt.Sprintf("column%d", i+1)
+			if i < len(header) && header[i] != "" {
+				name = header[i]
+			}
+			row[name] = field
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// xmlToValue converts an XML document to nested objects keyed by element
+// names. Attributes are prefixed with @, mixed text is stored under #text,
+// repeated elements become lists and text-only elements become strings.
+func xmlToValue(text string) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	// The text is already UTF-8, whatever the prolog declares
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := xmlElementValue(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// xmlElementValue reads an element's content up to its end tag
+func xmlElementValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	object := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		object["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := xmlElementValue(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			addXMLChild(object, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(object) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				object["#text"] = content
+			}
+			return object, nil
+		}
+	}
+}
+
+// addXMLChild adds a child element, turning repeated names into a list
+func addXMLChild(object map[string]interface{}, name string, child interface{}) {
+	existing, ok := object[name]
+	if !ok {
+		object[name] = child
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		object[name] = append(list, child)
+		return
+	}
+	object[name] = []interface{}{existing, child}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const itemsSpec = `openapi: 3.0.3
+info: {title: Items, version: "1"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        "200":
+          description: Items
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  items:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        id: {type: integer}
+                        name: {type: string}
+`
+
+// itemsUpstream serves n items from /items
+func itemsUpstream(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+
+	items := make([]map[string]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "name": fmt.Sprintf("item %d", i)}
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// resultSize returns the bytes of text and structured content in a tools/call result
+func resultSize(t *testing.T, result map[string]interface{}) int {
+	t.Helper()
+
+	size := 0
+	for _, block := range result["content"].([]map[string]interface{}) {
+		if text, ok := block["text"].(string); ok {
+			size += len(text)
+		}
+	}
+	if structured, ok := result["structuredContent"]; ok {
+		data, err := json.Marshal(structured)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += len(data)
+	}
+	return size
+}
+
+func TestLimitResultKeepsResultsWithinMaxBytes(t *testing.T) {
+	// The unlimited result of 40 items has this much text and structured content
+	unlimited := func(t *testing.T) (text, structured int) {
+		s := newTestServer(t, itemsSpec, testConfig(itemsUpstream(t, 40).URL))
+		result, err := s.executeTool(context.Background(), s.catalog().tool("listItems"), map[string]interface{}{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(result.Text), structuredSize(result.Structured)
+	}
+
+	tests := []struct {
+		name           string
+		maxBytes       func(text, structured int) int64
+		wantStructured bool
+		wantTruncated  bool
+		wantLink       bool
+	}{
+		{
+			name:           "within budget",
+			maxBytes:       func(text, structured int) int64 { return int64(text + structured) },
+			wantStructured: true,
+		},
+		{
+			name:     "text fits without structured content",
+			maxBytes: func(text, structured int) int64 { return int64(text + 300) },
+			wantLink: true,
+		},
+		{
+			name:          "oversized body",
+			maxBytes:      func(text, structured int) int64 { return int64(text / 2) },
+			wantTruncated: true,
+			wantLink:      true,
+		},
+		{
+			name:          "oversized body and small budget",
+			maxBytes:      func(text, structured int) int64 { return 800 },
+			wantTruncated: true,
+			wantLink:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxBytes := tt.maxBytes(unlimited(t))
+			cfg := testConfig(itemsUpstream(t, 40).URL)
+			cfg.Responses.MaxBytes = maxBytes
+			s := newTestServer(t, itemsSpec, cfg)
+
+			tool := s.catalog().tool("listItems")
+			if tool == nil || tool.OutputSchema == nil {
+				t.Fatal("listItems has no output schema")
+			}
+
+			result, err := s.executeTool(context.Background(), tool, map[string]interface{}{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response := toolCallResponse(testSession(), &MCPRequest{ID: 1}, result)
+			callResult := response.Result.(map[string]interface{})
+
+			if size := resultSize(t, callResult); int64(size) > maxBytes {
+				t.Errorf("result is %d bytes, over the %d-byte budget", size, maxBytes)
+			}
+			if _, ok := callResult["structuredContent"]; ok != tt.wantStructured {
+				t.Errorf("structuredContent present = %v, want %v", ok, tt.wantStructured)
+			}
+			if truncated := strings.Contains(result.Text, "[Truncated:"); truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+			if linked := len(result.Links) == 1; linked != tt.wantLink {
+				t.Errorf("linked = %v, want %v (%d links)", linked, tt.wantLink, len(result.Links))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+)
+
+// structuredOutputVersion is the first protocol version with outputSchema
+// and structuredContent
+const structuredOutputVersion = "2025-06-18"
+
+// wrappedResultProperty holds non-object results in structured content
+const wrappedResultProperty = "result"
+
+// generateOutputSchema builds the tool output schema from the JSON schema
+// shared by the operation's 2xx responses. Output schemas must describe an object, so
+// other schemas are wrapped in a "result" property, reported by wrapped.
+func (s *Server) generateOutputSchema(op parser.OperationInfo) (schema map[string]interface{}, wrapped bool) {
+	responseSchema := s.successResponseSchema(op)
+	if responseSchema == nil {
+		return nil, false
+	}
+
+	expanded := s.parser.ExpandSchema(s.spec, responseSchema)
+	converted := toJSONSchema(expanded)
+
+	// _fields may leave out any field, so projected results only keep the shape
+	if hasReservedArguments(&op) {
+		withoutRequired(converted)
+	}
+
+	if expanded.Type == "object" || (expanded.Type == "" && len(expanded.Properties) > 0) {
+		converted["type"] = "object"
+		return converted, false
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			wrappedResultProperty: converted,
+		},
+		"required": []string{wrappedResultProperty},
+	}, true
+}
+
+// withoutRequired removes the required keywords of a JSON schema and its
+// subschemas
+func withoutRequired(schema map[string]interface{}) {
+	delete(schema, "required")
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			if sub, ok := property.(map[string]interface{}); ok {
+				withoutRequired(sub)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		withoutRequired(items)
+	}
+	for _, keyword := range []string{"allOf", "oneOf", "anyOf"} {
+		subs, _ := schema[keyword].([]interface{})
+		for _, sub := range subs {
+			if sub, ok := sub.(map[string]interface{}); ok {
+				withoutRequired(sub)
+			}
+		}
+	}
+}
+
+// successResponseSchema returns the JSON schema shared by all 2xx responses.
+// It returns nil if a 2xx response has no JSON schema or the schemas differ,
+// as every successful call must return content matching the output schema.
+func (s *Server) successResponseSchema(op parser.OperationInfo) *parser.Schema {
+	var shared *parser.Schema
+	var sharedJSON []byte
+	for _, status := range sortedKeys(op.Operation.Responses) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+
+		schema := responseJSONSchema(op.Operation.Responses[status])
+		if schema == nil {
+			return nil
+		}
+		data, _ := json.Marshal(toJSONSchema(s.parser.ExpandSchema(s.spec, schema)))
+		if shared == nil {
+			shared, sharedJSON = schema, data
+			continue
+		}
+		if !bytes.Equal(data, sharedJSON) {
+			return nil
+		}
+	}
+
+	return shared
+}
+
+// responseJSONSchema returns the JSON schema of a response, or nil
+func responseJSONSchema(response parser.Response) *parser.Schema {
+	for _, mediaType := range sortedKeys(response.Content) {
+		if requester.IsJSONMediaType(mediaType) && response.Content[mediaType].Schema != nil {
+			return response.Content[mediaType].Schema
+		}
+	}
+	return response.Schema
+}
+
+// structuredContent returns the structured form of a response body for a
+// tool with an output schema. A tool that declares an output schema always
+// returns structured content, so bodies that are not objects are wrapped in
+// the "result" property.
+func structuredContent(tool *Tool, body interface{}) interface{} {
+	if tool.OutputSchema == nil {
+		return nil
+	}
+	if object, ok := body.(map[string]interface{}); ok && !tool.wrapOutput {
+		return object
+	}
+	return map[string]interface{}{wrappedResultProperty: body}
+}
+
+// toJSONSchema converts an expanded OpenAPI schema to JSON Schema. Remaining
+// references mark recursion and accept any value.
+func toJSONSchema(schema *parser.Schema) map[string]interface{} {
+	result := make(map[string]interface{})
+	if schema == nil || schema.Ref != "" {
+		return result
+	}
+
+	if schema.Type != "" {
+		if schema.Nullable {
+			result["type"] = []string{schema.Type, "null"}
+		} else {
+			result["type"] = schema.Type
+		}
+	}
+	if schema.Format != "" {
+		result["format"] = schema.Format
+	}
+	if schema.Description != "" {
+		result["description"] = schema.Description
+	}
+	if len(schema.Enum) > 0 {
+		enum := append([]interface{}{}, schema.Enum...)
+		if schema.Nullable {
+			enum = append(enum, nil)
+		}
+		result["enum"] = enum
+	}
+	if schema.Default != nil {
+		result["default"] = schema.Default
+	}
+	if schema.Example != nil {
+		result["examples"] = []interface{}{schema.Example}
+	}
+	if len(schema.Properties) > 0 {
+		properties := make(map[string]interface{}, len(schema.Properties))
+		for name, property := range schema.Properties {
+			properties[name] = toJSONSchema(property)
+		}
+		result["properties"] = properties
+	}
+	if len(schema.Required) > 0 {
+		result["required"] = schema.Required
+	}
+	if schema.Items != nil {
+		result["items"] = toJSONSchema(schema.Items)
+	}
+	for keyword, schemas := range map[string][]*parser.Schema{
+		"allOf": schema.AllOf,
+		"oneOf": schema.OneOf,
+		"anyOf": schema.AnyOf,
+	} {
+		if len(schemas) == 0 {
+			continue
+		}
+		converted := make([]interface{}, len(schemas))
+		for i, sub := range schemas {
+			converted[i] = toJSONSchema(sub)
+		}
+		result[keyword] = converted
+	}
+
+	return result
+}
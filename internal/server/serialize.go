@@ -0,0 +1,239 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// styleDelimiters are the separators of array and object values per style
+var styleDelimiters = map[string]string{
+	parser.StyleForm:           ",",
+	parser.StyleSimple:         ",",
+	parser.StyleSpaceDelimited: " ",
+	parser.StylePipeDelimited:  "|",
+	parser.StyleTabDelimited:   "\t",
+}
+
+// serializeQuery adds a query parameter to query following its style.
+// Values are stored unescaped; url.Values encodes them when the URL is built.
+func serializeQuery(query url.Values, param *parser.Parameter, value interface{}) {
+	style, explode := param.Serialization()
+	name := param.Name
+
+	switch v := value.(type) {
+	case []interface{}:
+		items := scalarList(v)
+		if explode {
+			query[name] = append(query[name], items...)
+			return
+		}
+		query.Add(name, strings.Join(items, delimiter(style)))
+
+	case map[string]interface{}:
+		if style == parser.StyleDeepObject {
+			addDeepObject(query, name, v)
+			return
+		}
+		if explode {
+			for _, key := range sortedKeys(v) {
+				query.Add(key, formatScalar(v[key]))
+			}
+			return
+		}
+		query.Add(name, strings.Join(keyValueList(v), delimiter(style)))
+
+	default:
+		query.Add(name, formatScalar(v))
+	}
+}
+
+// addDeepObject adds an object as name[key]=value pairs, nesting brackets for
+// nested objects and repeating the key for arrays
+func addDeepObject(query url.Values, name string, object map[string]interface{}) {
+	for _, key := range sortedKeys(object) {
+		field := fmt.Sprintf("%s[%s]", name, key)
+		switch v := object[key].(type) {
+		case map[string]interface{}:
+			addDeepObject(query, field, v)
+		case []interface{}:
+			query[field] = append(query[field], scalarList(v)...)
+		default:
+			query.Add(field, formatScalar(v))
+		}
+	}
+}
+
+// serializePath expands a path parameter following its style. Values are
+// percent-encoded so that only the style's own delimiters stay literal.
+func serializePath(param *parser.Parameter, value interface{}) string {
+	style, explode := param.Serialization()
+	name := url.PathEscape(param.Name)
+
+	var items []string
+	var pairs [][2]string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range scalarList(v) {
+			items = append(items, url.PathEscape(item))
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			pairs = append(pairs, [2]string{url.PathEscape(key), url.PathEscape(formatScalar(v[key]))})
+		}
+	default:
+		items = []string{url.PathEscape(formatScalar(v))}
+	}
+
+	switch style {
+	case parser.StyleLabel:
+		separator := ","
+		if explode {
+			separator = "."
+		}
+		return "." + joinStyled(items, pairs, separator, explode)
+
+	case parser.StyleMatrix:
+		if pairs != nil {
+			if explode {
+				return ";" + joinStyled(nil, pairs, ";", true)
+			}
+			return ";" + name + "=" + joinStyled(nil, pairs, ",", false)
+		}
+		if explode && len(items) > 1 {
+			return ";" + name + "=" + strings.Join(items, ";"+name+"=")
+		}
+		return ";" + name + "=" + strings.Join(items, ",")
+
+	default:
+		return joinStyled(items, pairs, ",", explode)
+	}
+}
+
+// serializeHeader formats a header parameter with the simple style
+func serializeHeader(param *parser.Parameter, value interface{}) string {
+	_, explode := param.Serialization()
+
+	switch v := value.(type) {
+	case []interface{}:
+		return strings.Join(scalarList(v), ",")
+	case map[string]interface{}:
+		var pairs [][2]string
+		for _, key := range sortedKeys(v) {
+			pairs = append(pairs, [2]string{key, formatScalar(v[key])})
+		}
+		return joinStyled(nil, pairs, ",", explode)
+	default:
+		return formatScalar(v)
+	}
+}
+
+// serializeCookie formats a cookie parameter with the form style as
+// name=value pairs for the Cookie header
+func serializeCookie(param *parser.Parameter, value interface{}) []string {
+	_, explode := param.Serialization()
+	name := cookieEscape(param.Name)
+
+	switch v := value.(type) {
+	case []interface{}:
+		items := scalarList(v)
+		for i, item := range items {
+			items[i] = cookieEscape(item)
+		}
+		if explode {
+			pairs := make([]string, len(items))
+			for i, item := range items {
+				pairs[i] = name + "=" + item
+			}
+			return pairs
+		}
+		return []string{name + "=" + strings.Join(items, ",")}
+
+	case map[string]interface{}:
+		if explode {
+			var pairs []string
+			for _, key := range sortedKeys(v) {
+				pairs = append(pairs, cookieEscape(key)+"="+cookieEscape(formatScalar(v[key])))
+			}
+			return pairs
+		}
+		list := keyValueList(v)
+		for i, item := range list {
+			list[i] = cookieEscape(item)
+		}
+		return []string{name + "=" + strings.Join(list, ",")}
+
+	default:
+		return []string{name + "=" + cookieEscape(formatScalar(v))}
+	}
+}
+
+// cookieEscape percent-encodes characters that are not allowed in cookie
+// names and values, such as separators and whitespace
+func cookieEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// joinStyled joins array items, or object pairs as key=value when exploded
+// and key,value otherwise
+func joinStyled(items []string, pairs [][2]string, separator string, explode bool) string {
+	if pairs == nil {
+		return strings.Join(items, separator)
+	}
+
+	parts := make([]string, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		if explode {
+			parts = append(parts, pair[0]+"="+pair[1])
+		} else {
+			parts = append(parts, pair[0], pair[1])
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// delimiter returns the separator of non-exploded values in a style
+func delimiter(style string) string {
+	if d, ok := styleDelimiters[style]; ok {
+		return d
+	}
+	return ","
+}
+
+// keyValueList flattens an object into key, value, key, value...
+func keyValueList(object map[string]interface{}) []string {
+	list := make([]string, 0, len(object)*2)
+	for _, key := range sortedKeys(object) {
+		list = append(list, key, formatScalar(object[key]))
+	}
+	return list
+}
+
+// scalarList formats the items of an array
+func scalarList(values []interface{}) []string {
+	list := make([]string, len(values))
+	for i, value := range values {
+		list[i] = formatScalar(value)
+	}
+	return list
+}
+
+// formatScalar formats a primitive value as it appears in a URL or header.
+// Numbers never use exponent notation.
+func formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
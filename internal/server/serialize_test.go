@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// The values of the style examples in the OpenAPI specification
+var (
+	colorArray  = []interface{}{"blue", "black", "brown"}
+	colorObject = map[string]interface{}{"R": 100.0, "G": 200.0, "B": 150.0}
+)
+
+func TestSerializeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		param parser.Parameter
+		value interface{}
+		want  string
+	}{
+		{name: "scalar", param: parser.Parameter{Name: "color"}, value: "blue", want: "color=blue"},
+		{name: "large number", param: parser.Parameter{Name: "n"}, value: 1e21, want: "n=1000000000000000000000"},
+		{name: "escaped", param: parser.Parameter{Name: "q"}, value: "a&b c", want: "q=a%26b+c"},
+		{name: "form array", param: parser.Parameter{Name: "color"}, value: colorArray, want: "color=blue&color=black&color=brown"},
+		{name: "form array not exploded", param: parser.Parameter{Name: "color", Explode: boolPtr(false)}, value: colorArray, want: "color=blue%2Cblack%2Cbrown"},
+		{name: "space delimited", param: parser.Parameter{Name: "color", Style: parser.StyleSpaceDelimited, Explode: boolPtr(false)}, value: colorArray, want: "color=blue+black+brown"},
+		{name: "pipe delimited", param: parser.Parameter{Name: "color", Style: parser.StylePipeDelimited, Explode: boolPtr(false)}, value: colorArray, want: "color=blue%7Cblack%7Cbrown"},
+		{name: "form object", param: parser.Parameter{Name: "color"}, value: colorObject, want: "B=150&G=200&R=100"},
+		{name: "form object not exploded", param: parser.Parameter{Name: "color", Explode: boolPtr(false)}, value: colorObject, want: "color=B%2C150%2CG%2C200%2CR%2C100"},
+		{name: "deep object", param: parser.Parameter{Name: "color", Style: parser.StyleDeepObject, Explode: boolPtr(true)}, value: colorObject, want: "color%5BB%5D=150&color%5BG%5D=200&color%5BR%5D=100"},
+		{
+			name:  "nested deep object",
+			param: parser.Parameter{Name: "filter", Style: parser.StyleDeepObject, Explode: boolPtr(true)},
+			value: map[string]interface{}{"range": map[string]interface{}{"min": 1.0}, "tags": []interface{}{"a", "b"}},
+			want:  "filter%5Brange%5D%5Bmin%5D=1&filter%5Btags%5D=a&filter%5Btags%5D=b",
+		},
+		{name: "swagger csv", param: parser.Parameter{Name: "color", Type: "array", CollectionFormat: "csv"}, value: colorArray, want: "color=blue%2Cblack%2Cbrown"},
+		{name: "swagger default", param: parser.Parameter{Name: "color", Type: "array"}, value: colorArray, want: "color=blue%2Cblack%2Cbrown"},
+		{name: "swagger ssv", param: parser.Parameter{Name: "color", Type: "array", CollectionFormat: "ssv"}, value: colorArray, want: "color=blue+black+brown"},
+		{name: "swagger tsv", param: parser.Parameter{Name: "color", Type: "array", CollectionFormat: "tsv"}, value: colorArray, want: "color=blue%09black%09brown"},
+		{name: "swagger multi", param: parser.Parameter{Name: "color", Type: "array", CollectionFormat: "multi"}, value: colorArray, want: "color=blue&color=black&color=brown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := tt.param
+			param.In = "query"
+			query := make(url.Values)
+			serializeQuery(query, &param, tt.value)
+			if got := query.Encode(); got != tt.want {
+				t.Errorf("query = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		explode bool
+		value   interface{}
+		want    string
+	}{
+		{name: "simple", value: "blue", want: "blue"},
+		{name: "simple escaped", value: "a/b c", want: "a%2Fb%20c"},
+		{name: "simple array", value: colorArray, want: "blue,black,brown"},
+		{name: "simple array exploded", explode: true, value: colorArray, want: "blue,black,brown"},
+		{name: "simple object", value: colorObject, want: "B,150,G,200,R,100"},
+		{name: "simple object exploded", explode: true, value: colorObject, want: "B=150,G=200,R=100"},
+		{name: "label", style: parser.StyleLabel, value: "blue", want: ".blue"},
+		{name: "label array", style: parser.StyleLabel, value: colorArray, want: ".blue,black,brown"},
+		{name: "label array exploded", style: parser.StyleLabel, explode: true, value: colorArray, want: ".blue.black.brown"},
+		{name: "label object", style: parser.StyleLabel, value: colorObject, want: ".B,150,G,200,R,100"},
+		{name: "label object exploded", style: parser.StyleLabel, explode: true, value: colorObject, want: ".B=150.G=200.R=100"},
+		{name: "matrix", style: parser.StyleMatrix, value: "blue", want: ";color=blue"},
+		{name: "matrix array", style: parser.StyleMatrix, value: colorArray, want: ";color=blue,black,brown"},
+		{name: "matrix array exploded", style: parser.StyleMatrix, explode: true, value: colorArray, want: ";color=blue;color=black;color=brown"},
+		{name: "matrix object", style: parser.StyleMatrix, value: colorObject, want: ";color=B,150,G,200,R,100"},
+		{name: "matrix object exploded", style: parser.StyleMatrix, explode: true, value: colorObject, want: ";B=150;G=200;R=100"},
+		{name: "matrix escaped", style: parser.StyleMatrix, value: "a;b", want: ";color=a%3Bb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &parser.Parameter{Name: "color", In: "path", Style: tt.style, Explode: boolPtr(tt.explode)}
+			if got := serializePath(param, tt.value); got != tt.want {
+				t.Errorf("serializePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		explode bool
+		value   interface{}
+		want    string
+	}{
+		{name: "scalar", value: 5.0, want: "5"},
+		{name: "boolean", value: true, want: "true"},
+		{name: "array", value: colorArray, want: "blue,black,brown"},
+		{name: "array exploded", explode: true, value: colorArray, want: "blue,black,brown"},
+		{name: "object", value: colorObject, want: "B,150,G,200,R,100"},
+		{name: "object exploded", explode: true, value: colorObject, want: "B=150,G=200,R=100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &parser.Parameter{Name: "X-Color", In: "header", Explode: boolPtr(tt.explode)}
+			if got := serializeHeader(param, tt.value); got != tt.want {
+				t.Errorf("serializeHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		explode *bool
+		value   interface{}
+		want    []string
+	}{
+		{name: "scalar", value: "blue", want: []string{"color=blue"}},
+		{name: "escaped", value: "a; b=c", want: []string{"color=a%3B%20b%3Dc"}},
+		{name: "array", value: colorArray, want: []string{"color=blue", "color=black", "color=brown"}},
+		{name: "array not exploded", explode: boolPtr(false), value: colorArray, want: []string{"color=blue,black,brown"}},
+		{name: "object", value: colorObject, want: []string{"B=150", "G=200", "R=100"}},
+		{name: "object not exploded", explode: boolPtr(false), value: colorObject, want: []string{"color=B,150,G,200,R,100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &parser.Parameter{Name: "color", In: "cookie", Explode: tt.explode}
+			if got := serializeCookie(param, tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serializeCookie() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,20 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/feitianbubu/oas-mcp/internal/config"
 	"github.com/feitianbubu/oas-mcp/internal/logger"
@@ -28,7 +35,16 @@ type Server struct {
 	parser    *parser.Parser
 	requester *requester.Requester
 	spec      *parser.OpenAPISpec
-	tools     []Tool
+	lookups   lookupCache
+	filter    *operationFilter
+	responses *responseStore
+	templates map[string]*template.Template
+
+	// promptTemplates are the parsed templates of user-defined prompts
+	promptTemplates map[string]*template.Template
+
+	// current is the tool catalog, replaced atomically when rebuilt
+	current atomic.Pointer[catalog]
 }
 
 // NewServer creates a new server instance
@@ -48,25 +64,62 @@ func NewServer(cfg *config.Config, p *parser.Parser, r *requester.Requester) (*S
 		zap.String("title", spec.Info.Title),
 		zap.String("version", spec.Info.Version))
 
+	// Fill in auth settings the configuration leaves to the spec
+	r.UseSecuritySchemes(spec.SecuritySchemes())
+
 	server := &Server{
 		config:    cfg,
 		parser:    p,
 		requester: r,
 		spec:      spec,
+		responses: newResponseStore(cfg.Responses.StoreBytes, time.Duration(cfg.Responses.StoreTTL)*time.Second),
+	}
+
+	server.filter, err = newOperationFilter(cfg.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	server.templates, err = parseResponseTemplates(cfg.Responses)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate tools from the OpenAPI spec
-	server.generateTools()
+	if err := server.generateTools(); err != nil {
+		return nil, err
+	}
+
+	if err := server.validatePrompts(); err != nil {
+		return nil, err
+	}
+	if err := server.validateCompletions(); err != nil {
+		return nil, err
+	}
 
 	return server, nil
 }
 
 // Tool represents an MCP tool
 type Tool struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	InputSchema Schema `json:"inputSchema"`
-	Operation   *parser.OperationInfo
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  Schema                 `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+	Operation    *parser.OperationInfo  `json:"-"`
+
+	// wrapOutput is set when the output schema wraps a non-object response
+	wrapOutput bool
+}
+
+// toolResult is the outcome of a tool call
+type toolResult struct {
+	Text        string
+	Structured  interface{}
+	Attachments []attachment
+	Links       []resourceLink
+	IsError     bool
 }
 
 // Schema represents a JSON schema for tool input
@@ -79,18 +132,22 @@ type Schema struct {
 // Property represents a property in a JSON schema
 type Property struct {
 	Type        string      `json:"type"`
+	Format      string      `json:"format,omitempty"`
 	Description string      `json:"description,omitempty"`
 	Items       *Property   `json:"items,omitempty"`
 	Enum        []string    `json:"enum,omitempty"`
 	Example     interface{} `json:"example,omitempty"`
 }
 
-// MCPRequest represents an MCP request
+// MCPRequest represents an MCP request. Responses from the client to
+// server-initiated requests arrive in the same shape with Result or Error set.
 type MCPRequest struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      interface{} `json:"id"`
-	Method  string      `json:"method"`
-	Params  interface{} `json:"params,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *MCPError       `json:"error,omitempty"`
 }
 
 // MCPResponse represents an MCP response
@@ -113,7 +170,7 @@ func (s *Server) Start(ctx context.Context) error {
 	logger.Info("Starting MCP server",
 		zap.String("mode", s.config.Server.Mode),
 		zap.String("swagger_file", s.config.SwaggerFile),
-		zap.Int("tools_count", len(s.tools)))
+		zap.Int("tools_count", len(s.catalog().tools)))
 
 	switch s.config.Server.Mode {
 	case config.ServerModeSTDIO:
@@ -129,34 +186,128 @@ func (s *Server) Start(ctx context.Context) error {
 
 // startSTDIOServer starts the STDIO server
 func (s *Server) startSTDIOServer(ctx context.Context) error {
-	logger.Info("Starting STDIO MCP server")
+	logger.Info("Starting STDIO MCP server",
+		zap.Int("max_concurrency", s.config.Server.MaxConcurrency))
 
-	// Read from stdin and write to stdout
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
+	// All output goes through a single writer so messages never interleave
+	messages := make(chan interface{}, 64)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		broken := false
+		for message := range messages {
+			data, err := json.Marshal(message)
+			if err != nil {
+				logger.Error("Failed to encode message", zap.Error(err))
+				continue
+			}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			var request MCPRequest
-			if err := decoder.Decode(&request); err != nil {
-				if err == io.EOF {
-					return nil
-				}
-				logger.Error("Failed to decode request", zap.Error(err))
+			// Once stdout fails there is nobody left to talk to; keep draining
+			if broken {
 				continue
 			}
+			if _, err := os.Stdout.Write(append(data, '\n')); err != nil {
+				broken = true
+				logger.Error("Failed to write to stdout", zap.Error(err))
+			}
+		}
+	}()
+
+	sess := newSession(func(message interface{}) error {
+		messages <- message
+		return nil
+	})
+	sess.forwardLogs()
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		sess.close()
+		close(messages)
+		<-writerDone
+	}()
+
+	// Limit the number of requests processed at the same time
+	workers := make(chan struct{}, s.config.Server.MaxConcurrency)
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-			response := s.handleRequest(&request)
-			if err := encoder.Encode(response); err != nil {
-				logger.Error("Failed to encode response", zap.Error(err))
+		// Read one message per line so a malformed line can be skipped
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.dispatchSTDIOMessage(ctx, sess, line, workers, &wg)
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
 			}
+			return fmt.Errorf("failed to read from stdin: %w", err)
 		}
 	}
 }
 
+// dispatchSTDIOMessage decodes one line from stdin and handles it. Requests are
+// processed concurrently, bounded by workers; notifications are handled inline.
+func (s *Server) dispatchSTDIOMessage(ctx context.Context, sess *session, line []byte, workers chan struct{}, wg *sync.WaitGroup) {
+	var request MCPRequest
+	if err := json.Unmarshal(line, &request); err != nil {
+		logger.Error("Failed to decode request", logger.SessionID(sess.id), zap.Error(err))
+		sess.write(&MCPResponse{
+			JSONRPC: "2.0",
+			Error: &MCPError{
+				Code:    -32700,
+				Message: "Parse error",
+			},
+		})
+		return
+	}
+
+	if request.ID == nil {
+		s.handleNotification(sess, &request)
+		return
+	}
+
+	if request.Method == "" {
+		if !sess.resolve(&request) {
+			logger.Warn("Received response to unknown request", logger.SessionID(sess.id), zap.Any("id", request.ID))
+		}
+		return
+	}
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	sess.track(request.ID, cancel)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		defer sess.untrack(request.ID)
+
+		select {
+		case workers <- struct{}{}:
+			defer func() { <-workers }()
+		case <-reqCtx.Done():
+			return
+		}
+
+		response := s.handleRequest(reqCtx, sess, &request)
+
+		// Cancelled requests are not answered
+		if reqCtx.Err() != nil && ctx.Err() == nil {
+			logger.Debug("Request cancelled", logger.SessionID(sess.id), zap.Any("id", request.ID))
+			return
+		}
+
+		if err := sess.write(response); err != nil {
+			logger.Error("Failed to encode response", logger.SessionID(sess.id), zap.Error(err))
+		}
+	}()
+}
+
 // startHTTPServer starts the HTTP server
 func (s *Server) startHTTPServer(ctx context.Context) error {
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
@@ -241,12 +392,54 @@ func (s *Server) handleHTTPRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := s.handleRequest(&request)
+	if request.ID == nil {
+		s.handleNotification(newSession(nil), &request)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Stream tool calls to clients that accept event streams so that
+	// progress notifications arrive before the result
+	if request.Method == "tools/call" && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		if sess, ok := newEventStreamSession(w); ok {
+			defer sess.close()
+			sess.setProtocolVersion(httpProtocolVersion(r))
+			response := s.handleRequest(r.Context(), sess, &request)
+			if err := sess.write(response); err != nil {
+				logger.Error("Failed to write response", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	sess := newSession(nil)
+	sess.setProtocolVersion(httpProtocolVersion(r))
+	response := s.handleRequest(r.Context(), sess, &request)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// httpProtocolVersion returns the protocol version announced by an HTTP client.
+// Clients that do not send the header are assumed to speak 2025-03-26.
+func httpProtocolVersion(r *http.Request) string {
+	if version := r.Header.Get("MCP-Protocol-Version"); version != "" {
+		return negotiateProtocolVersion(version)
+	}
+	return "2025-03-26"
+}
+
+// withoutOutputSchemas returns copies of tools without output schemas, for
+// clients on protocol versions that predate them
+func withoutOutputSchemas(tools []Tool) []Tool {
+	result := make([]Tool, len(tools))
+	for i, tool := range tools {
+		tool.OutputSchema = nil
+		result[i] = tool
+	}
+	return result
+}
+
 // handleSSERequest handles SSE requests
 func (s *Server) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -273,18 +466,42 @@ func (s *Server) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleRequest handles MCP requests
-func (s *Server) handleRequest(request *MCPRequest) *MCPResponse {
+func (s *Server) handleRequest(ctx context.Context, sess *session, request *MCPRequest) *MCPResponse {
+	// Records logged while handling the request go to this session only
+	ctx = logger.WithSession(ctx, sess.id)
+
 	logger.Debug("Handling MCP request",
+		logger.SessionID(sess.id),
 		zap.String("method", request.Method),
 		zap.Any("id", request.ID))
 
 	switch request.Method {
 	case "initialize":
-		return s.handleInitialize(request)
+		return s.handleInitialize(sess, request)
+	case "ping":
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Result:  map[string]interface{}{},
+		}
 	case "tools/list":
-		return s.handleToolsList(request)
+		return s.handleToolsList(sess, request)
 	case "tools/call":
-		return s.handleToolsCall(request)
+		return s.handleToolsCall(ctx, sess, request)
+	case "resources/list":
+		return s.handleResourcesList(request)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(request)
+	case "resources/read":
+		return s.handleResourcesRead(request)
+	case "prompts/list":
+		return s.handlePromptsList(request)
+	case "prompts/get":
+		return s.handlePromptsGet(request)
+	case "completion/complete":
+		return s.handleCompletionComplete(ctx, request)
+	case "logging/setLevel":
+		return s.handleLoggingSetLevel(sess, request)
 	default:
 		return &MCPResponse{
 			JSONRPC: "2.0",
@@ -297,13 +514,60 @@ func (s *Server) handleRequest(request *MCPRequest) *MCPResponse {
 	}
 }
 
+// handleNotification handles MCP notifications, which never get a response
+func (s *Server) handleNotification(sess *session, notification *MCPRequest) {
+	logger.Debug("Handling MCP notification", logger.SessionID(sess.id), zap.String("method", notification.Method))
+
+	switch notification.Method {
+	case "notifications/cancelled":
+		params, _ := notification.Params.(map[string]interface{})
+		requestID := params["requestId"]
+		if requestID != nil && sess.cancel(requestID) {
+			logger.Debug("Cancelling request",
+				logger.SessionID(sess.id),
+				zap.Any("id", requestID),
+				zap.Any("reason", params["reason"]))
+		}
+	}
+}
+
+// supportedProtocolVersions lists the MCP protocol versions the server speaks, newest first
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// negotiateProtocolVersion returns the requested version if supported, otherwise the latest
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range supportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return supportedProtocolVersions[0]
+}
+
 // handleInitialize handles initialize requests
-func (s *Server) handleInitialize(request *MCPRequest) *MCPResponse {
+func (s *Server) handleInitialize(sess *session, request *MCPRequest) *MCPResponse {
+	params, _ := request.Params.(map[string]interface{})
+	requested, _ := params["protocolVersion"].(string)
+	version := negotiateProtocolVersion(requested)
+	sess.setProtocolVersion(version)
+
+	capabilities, _ := params["capabilities"].(map[string]interface{})
+	sess.setClientCapabilities(capabilities)
+
+	serverCapabilities := map[string]interface{}{
+		"tools":       map[string]interface{}{},
+		"resources":   map[string]interface{}{},
+		"prompts":     map[string]interface{}{},
+		"completions": map[string]interface{}{},
+	}
+	// Only sessions that outlive a single request can receive log messages
+	if sess.forwardsLogs() {
+		serverCapabilities["logging"] = map[string]interface{}{}
+	}
+
 	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
-		},
+		"protocolVersion": version,
+		"capabilities":    serverCapabilities,
 		"serverInfo": map[string]interface{}{
 			"name":    "oas-mcp",
 			"version": "1.0.0",
@@ -318,9 +582,25 @@ func (s *Server) handleInitialize(request *MCPRequest) *MCPResponse {
 }
 
 // handleToolsList handles tools/list requests
-func (s *Server) handleToolsList(request *MCPRequest) *MCPResponse {
+func (s *Server) handleToolsList(sess *session, request *MCPRequest) *MCPResponse {
+	cat := s.catalog()
+	start, end, nextCursor, err := paginate(request.Params, len(cat.listed), s.config.Server.PageSize, cat.version)
+	if err != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid cursor: %v", err),
+			},
+		}
+	}
+
 	result := map[string]interface{}{
-		"tools": s.tools,
+		"tools": cat.listPayload(start, end, sess.supports(structuredOutputVersion)),
+	}
+	if nextCursor != "" {
+		result["nextCursor"] = nextCursor
 	}
 
 	return &MCPResponse{
@@ -331,7 +611,7 @@ func (s *Server) handleToolsList(request *MCPRequest) *MCPResponse {
 }
 
 // handleToolsCall handles tools/call requests
-func (s *Server) handleToolsCall(request *MCPRequest) *MCPResponse {
+func (s *Server) handleToolsCall(ctx context.Context, sess *session, request *MCPRequest) *MCPResponse {
 	params, ok := request.Params.(map[string]interface{})
 	if !ok {
 		return &MCPResponse{
@@ -357,29 +637,52 @@ func (s *Server) handleToolsCall(request *MCPRequest) *MCPResponse {
 	}
 
 	arguments, _ := params["arguments"].(map[string]interface{})
+	meta, _ := params["_meta"].(map[string]interface{})
 
 	// Find the tool
-	var tool *Tool
-	for _, t := range s.tools {
-		if t.Name == toolName {
-			tool = &t
-			break
+	tool := s.catalog().tool(toolName)
+	if tool == nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Tool not found",
+			},
 		}
 	}
 
-	if tool == nil {
+	// Meta-tools of the discovery mode are served by the server itself
+	if tool.Operation == nil {
+		return toolCallResponse(sess, request, s.callMetaTool(ctx, sess, tool.Name, arguments, sess.progressReporter(meta["progressToken"])))
+	}
+
+	// Operations hidden by the tools filters must never be called
+	if !s.filter.allows(s.spec, tool.Operation) {
 		return &MCPResponse{
 			JSONRPC: "2.0",
 			ID:      request.ID,
 			Error: &MCPError{
 				Code:    -32602,
-				Message: "Tool not found",
+				Message: fmt.Sprintf("Tool not available: %s", toolName),
 			},
 		}
 	}
 
+	// Ask the user for missing required parameters, or explain what is missing
+	arguments, failure := s.resolveMissingArguments(ctx, sess, tool, arguments)
+	if failure != nil {
+		return toolCallResponse(sess, request, failure)
+	}
+
+	// Report every invalid argument at once so the model can fix them together
+	arguments, violations := s.validateArguments(tool, arguments)
+	if len(violations) > 0 {
+		return toolCallResponse(sess, request, invalidArgumentsResult(tool.Name, violations, "Fix these arguments and call the tool again."))
+	}
+
 	// Execute the tool
-	result, err := s.executeTool(tool, arguments)
+	result, err := s.executeTool(ctx, tool, arguments, sess.progressReporter(meta["progressToken"]))
 	if err != nil {
 		return &MCPResponse{
 			JSONRPC: "2.0",
@@ -391,89 +694,211 @@ func (s *Server) handleToolsCall(request *MCPRequest) *MCPResponse {
 		}
 	}
 
+	return toolCallResponse(sess, request, result)
+}
+
+// toolCallResponse builds the tools/call response for a tool result
+func toolCallResponse(sess *session, request *MCPRequest, result *toolResult) *MCPResponse {
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": result.Text,
+		},
+	}
+	for _, a := range result.Attachments {
+		content = append(content, a.contentBlock(sess))
+	}
+	if sess.supports(resourceLinkVersion) {
+		for _, link := range result.Links {
+			content = append(content, link.contentBlock())
+		}
+	}
+
+	callResult := map[string]interface{}{
+		"content": content,
+	}
+	if result.Structured != nil && sess.supports(structuredOutputVersion) {
+		callResult["structuredContent"] = result.Structured
+	}
+	if result.IsError {
+		callResult["isError"] = true
+	}
+
 	return &MCPResponse{
 		JSONRPC: "2.0",
 		ID:      request.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": result,
-				},
-			},
-		},
+		Result:  callResult,
 	}
 }
 
-// executeTool executes a tool
-func (s *Server) executeTool(tool *Tool, arguments map[string]interface{}) (string, error) {
+// executeTool executes a tool, reporting upstream progress if progress is set
+func (s *Server) executeTool(ctx context.Context, tool *Tool, arguments map[string]interface{}, progress requester.ProgressFunc) (*toolResult, error) {
 	logger.Debug("Executing tool",
+		logger.Session(ctx),
 		zap.String("tool", tool.Name),
 		zap.Any("arguments", arguments))
 
+	projection, err := parseProjection(tool.Operation, arguments)
+	if err != nil {
+		return &toolResult{Text: err.Error(), IsError: true}, nil
+	}
+
+	// Upstream failures are tool errors the model can react to, not protocol errors
+	response, err := s.callOperation(ctx, tool.Operation, arguments, progress)
+	if err != nil {
+		return &toolResult{Text: transportErrorText(tool.Operation, err), IsError: true}, nil
+	}
+	if response.StatusCode >= 400 {
+		return &toolResult{Text: upstreamErrorText(tool.Operation, response), IsError: true}, nil
+	}
+
+	// Render the response; binary bodies are attached as image, audio or resource content
+	body, attachments := responseBody(response)
+
+	// Apply the _fields and _limit arguments to parsed bodies
+	var notes []string
+	if projection != nil && attachments == nil {
+		body, notes = projection.apply(body)
+	}
+
+	text := s.renderResponse(ctx, tool.Operation.Operation.OperationID, response.StatusCode, response.Headers, body)
+
+	result := &toolResult{Text: text, Attachments: attachments}
+	if len(notes) > 0 {
+		result.Text += "\n\n" + strings.Join(notes, "\n")
+	}
+
+	// Structured content is the same, possibly projected, body as the text
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		result.Structured = structuredContent(tool, body)
+	}
+
+	// Keep oversized responses out of the model context
+	s.limitResult(ctx, tool.Operation, response, result)
+
+	return result, nil
+}
+
+// headerValues formats response headers for tool output: a single value is
+// shown as a string, repeated headers such as Set-Cookie as a list
+func headerValues(headers http.Header) map[string]interface{} {
+	result := make(map[string]interface{}, len(headers))
+	for key, values := range headers {
+		if len(values) == 1 {
+			result[key] = values[0]
+		} else {
+			result[key] = values
+		}
+	}
+	return result
+}
+
+// callOperation sends the upstream request for an operation built from arguments
+func (s *Server) callOperation(ctx context.Context, op *parser.OperationInfo, arguments map[string]interface{}, progress requester.ProgressFunc) (*requester.Response, error) {
 	// Build request from arguments
 	req := &requester.Request{
-		Method:  tool.Operation.Method,
-		Path:    tool.Operation.Path,
-		Headers: make(map[string]string),
-		Query:   make(map[string]string),
+		Method:   op.Method,
+		Path:     op.Path,
+		Headers:  make(http.Header),
+		Query:    make(url.Values),
+		Progress: progress,
+
+		MaxBodyBytes: s.config.Responses.MaxReadBytes,
 	}
 
-	// Extract parameters from arguments based on OpenAPI spec
-	for _, param := range tool.Operation.Operation.Parameters {
+	// An operation's security requirements override the spec's global ones
+	req.Security = op.Operation.Security
+	if req.Security == nil {
+		req.Security = s.spec.Security
+	}
+
+	// Serialize parameters from arguments following their style and explode settings
+	var cookies []string
+	for i := range op.Operation.Parameters {
+		param := &op.Operation.Parameters[i]
 		if value, exists := arguments[param.Name]; exists {
 			switch param.In {
 			case "query":
-				req.Query[param.Name] = fmt.Sprintf("%v", value)
+				serializeQuery(req.Query, param, value)
 			case "header":
-				req.Headers[param.Name] = fmt.Sprintf("%v", value)
+				req.Headers.Set(param.Name, serializeHeader(param, value))
+			case "cookie":
+				cookies = append(cookies, serializeCookie(param, value)...)
 			case "path":
 				// Replace path parameters
-				req.Path = strings.ReplaceAll(req.Path, "{"+param.Name+"}", fmt.Sprintf("%v", value))
+				req.Path = strings.ReplaceAll(req.Path, "{"+param.Name+"}", serializePath(param, value))
 			}
 		}
 	}
 
-	// Handle request body
-	if tool.Operation.Operation.RequestBody != nil {
-		if bodyData, exists := arguments["body"]; exists {
-			req.Body = bodyData
-		}
+	if len(cookies) > 0 {
+		req.Headers.Add("Cookie", strings.Join(cookies, "; "))
 	}
 
+	// Encode the request body for the selected media type
+	body, contentType, err := s.encodeRequestBody(op, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	req.Body = body
+	req.ContentType = contentType
+
 	// Execute the request
-	ctx := context.Background()
 	response, err := s.requester.Execute(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 
-	// Format response
-	resultData, _ := json.MarshalIndent(map[string]interface{}{
-		"status_code": response.StatusCode,
-		"headers":     response.Headers,
-		"body":        response.Body,
-	}, "", "  ")
-
-	return string(resultData), nil
+	return response, nil
 }
 
-// generateTools generates MCP tools from OpenAPI operations
-func (s *Server) generateTools() {
+// generateTools generates MCP tools from OpenAPI operations and installs
+// them as the current catalog
+func (s *Server) generateTools() error {
 	operations := s.parser.GetOperations(s.spec)
 
+	var tools []Tool
+	hidden := 0
 	for _, op := range operations {
+		if !s.filter.allows(s.spec, &op) {
+			hidden++
+			continue
+		}
+
 		tool := Tool{
 			Name:        s.generateToolName(op),
 			Description: s.generateToolDescription(op),
 			InputSchema: s.generateInputSchema(op),
+			Annotations: s.generateAnnotations(op),
 			Operation:   &op,
 		}
+		tool.OutputSchema, tool.wrapOutput = s.generateOutputSchema(op)
 
-		s.tools = append(s.tools, tool)
+		tools = append(tools, tool)
 	}
 
-	logger.Info("Generated tools from OpenAPI spec", zap.Int("count", len(s.tools)))
+	listed := tools
+	if s.config.Tools.Mode == config.ToolsModeDiscovery {
+		listed = metaTools()
+	}
+
+	cat, err := newCatalog(tools, listed)
+	if err != nil {
+		return fmt.Errorf("failed to build tool catalog: %w", err)
+	}
+	s.current.Store(cat)
+
+	logger.Info("Generated tools from OpenAPI spec",
+		zap.Int("count", len(cat.tools)),
+		zap.Int("filtered", hidden),
+		zap.String("catalog_version", cat.version))
+
+	return nil
+}
+
+// catalog returns the current tool catalog
+func (s *Server) catalog() *catalog {
+	return s.current.Load()
 }
 
 // generateToolName generates a tool name from an operation
@@ -505,13 +930,13 @@ func (s *Server) generateInputSchema(op parser.OperationInfo) Schema {
 
 	// Add parameters
 	for _, param := range op.Operation.Parameters {
-		property := Property{
-			Type:        "string", // Simplified - should be based on param.Schema.Type
-			Description: param.Description,
+		property := s.parameterProperty(param.EffectiveSchema())
+		property.Description = param.Description
+		if param.In == "body" {
+			property = s.bodyProperty(&op, param.Description)
 		}
-
-		if param.Schema != nil && param.Schema.Type != "" {
-			property.Type = param.Schema.Type
+		if param.Type == "file" {
+			property = Property{Type: "string", Description: strings.TrimSpace(param.Description + " " + fileValueHint)}
 		}
 
 		schema.Properties[param.Name] = property
@@ -523,19 +948,54 @@ func (s *Server) generateInputSchema(op parser.OperationInfo) Schema {
 
 	// Add request body
 	if op.Operation.RequestBody != nil {
-		schema.Properties["body"] = Property{
-			Type:        "object",
-			Description: op.Operation.RequestBody.Description,
-		}
+		schema.Properties["body"] = s.bodyProperty(&op, op.Operation.RequestBody.Description)
 
 		if op.Operation.RequestBody.Required {
 			schema.Required = append(schema.Required, "body")
 		}
 	}
 
+	// Let the caller choose when several request media types are offered
+	if mediaTypes := requestMediaTypes(&op); len(mediaTypes) > 1 {
+		schema.Properties[bodyContentTypeArgument] = mediaTypeProperty(mediaTypes)
+	}
+
+	// Let the caller select only the parts of the response it needs
+	if hasReservedArguments(&op) {
+		for name, property := range projectionProperties() {
+			schema.Properties[name] = property
+		}
+	}
+
 	return schema
 }
 
+// parameterProperty describes a parameter schema as an input schema
+// property. Parameters without a type are passed as strings.
+func (s *Server) parameterProperty(schema *parser.Schema) Property {
+	property := Property{Type: "string"}
+
+	schema = s.parser.ResolveSchema(s.spec, schema)
+	if schema == nil {
+		return property
+	}
+
+	if schema.Type != "" {
+		property.Type = schema.Type
+	}
+	property.Format = schema.Format
+	if property.Type == "string" {
+		property.Enum = scalarStrings(schema.Enum)
+	}
+	property.Example = schema.Example
+	if schema.Type == "array" {
+		items := s.parameterProperty(schema.Items)
+		property.Items = &items
+	}
+
+	return property
+}
+
 // handleConfigRequest handles config API requests
 func (s *Server) handleConfigRequest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/config"
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+)
+
+// testConfig returns the configuration defaults for an upstream at baseURL
+func testConfig(baseURL string) *config.Config {
+	return &config.Config{
+		Upstream: config.Upstream{
+			BaseURL: baseURL,
+			Timeout: 5,
+		},
+		Auth:  config.Auth{Type: "none"},
+		Tools: config.Tools{Mode: config.ToolsModeOperations},
+		Responses: config.Responses{
+			Format:       "json",
+			MaxReadBytes: 64 << 20,
+			StoreBytes:   64 << 20,
+			StoreTTL:     3600,
+		},
+	}
+}
+
+// newTestServer creates a server for an OpenAPI document
+func newTestServer(t *testing.T, spec string, cfg *config.Config) *Server {
+	t.Helper()
+
+	cfg.SwaggerFile = filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(cfg.SwaggerFile, []byte(spec), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(cfg, parser.NewParser(), requester.NewRequester(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testSession returns a session that negotiated the latest protocol version
+func testSession() *session {
+	sess := newSession(nil)
+	sess.setProtocolVersion(supportedProtocolVersions[0])
+	return sess
+}
+
+func TestHeaderValues(t *testing.T) {
+	headers := http.Header{
+		"Content-Type": {"application/json"},
+		"Set-Cookie":   {"a=1", "b=2"},
+	}
+	want := map[string]interface{}{
+		"Content-Type": "application/json",
+		"Set-Cookie":   []string{"a=1", "b=2"},
+	}
+	if got := headerValues(headers); !reflect.DeepEqual(got, want) {
+		t.Errorf("headerValues() = %v, want %v", got, want)
+	}
+}
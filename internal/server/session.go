@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/feitianbubu/oas-mcp/internal/logger"
+	"github.com/feitianbubu/oas-mcp/internal/requester"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MCPNotification represents an MCP notification
+type MCPNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// session holds the state of one connected client and the channel
+// used to push server-initiated messages back to it
+type session struct {
+	mu           sync.Mutex
+	id           string
+	send         func(message interface{}) error
+	inflight     map[string]context.CancelFunc
+	logLevel     zapcore.Level
+	subscription *logger.Subscription
+
+	// protocolVersion is the MCP protocol version agreed at initialization
+	protocolVersion string
+
+	// clientCapabilities are the capabilities the client declared at initialization
+	clientCapabilities map[string]interface{}
+
+	// pending holds server-initiated requests awaiting a client response
+	pending       map[string]chan *MCPRequest
+	nextRequestID int
+}
+
+// sessionCount numbers the sessions to tie log records to them
+var sessionCount atomic.Int64
+
+// newSession creates a session that delivers messages through send.
+// A nil send creates a session that cannot receive notifications. Log
+// records are only forwarded once forwardLogs is called.
+func newSession(send func(message interface{}) error) *session {
+	sess := &session{
+		id:       strconv.FormatInt(sessionCount.Add(1), 10),
+		send:     send,
+		inflight: make(map[string]context.CancelFunc),
+		logLevel: defaultSessionLogLevel,
+		pending:  make(map[string]chan *MCPRequest),
+	}
+
+	return sess
+}
+
+// setProtocolVersion records the negotiated protocol version
+func (s *session) setProtocolVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = version
+}
+
+// setClientCapabilities records the capabilities declared by the client
+func (s *session) setClientCapabilities(capabilities map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientCapabilities = capabilities
+}
+
+// hasCapability reports whether the client declared a capability and the
+// transport can deliver requests to it
+func (s *session) hasCapability(name string) bool {
+	if !s.canSend() {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.clientCapabilities[name]
+	return ok
+}
+
+// request sends a request to the client and waits for its response
+func (s *session) request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.mu.Lock()
+	s.nextRequestID++
+	id := fmt.Sprintf("oas-mcp-%d", s.nextRequestID)
+	responses := make(chan *MCPRequest, 1)
+	s.pending[id] = responses
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	request := &MCPRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+	if err := s.write(request); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response := <-responses:
+		if response.Error != nil {
+			return nil, fmt.Errorf("client rejected %s request: %s", method, response.Error.Message)
+		}
+		return response.Result, nil
+	}
+}
+
+// resolve delivers a client response to the request waiting for it,
+// reporting whether such a request exists. Each request takes one response;
+// duplicates are reported as unknown.
+func (s *session) resolve(response *MCPRequest) bool {
+	id, ok := response.ID.(string)
+	if !ok {
+		return false
+	}
+
+	s.mu.Lock()
+	responses, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+
+	if ok {
+		// Never block the reader, even if the request has stopped waiting
+		select {
+		case responses <- response:
+		default:
+		}
+	}
+	return ok
+}
+
+// supports reports whether the negotiated protocol version is at least version
+func (s *session) supports(version string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Protocol versions are dates, so they compare correctly as strings
+	return s.protocolVersion >= version
+}
+
+// requestKey returns a map key for a JSON-RPC id, keeping 1 and "1" distinct
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// track registers the cancel function of an in-flight request
+func (s *session) track(id interface{}, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[requestKey(id)] = cancel
+}
+
+// untrack removes a finished request
+func (s *session) untrack(id interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, requestKey(id))
+}
+
+// cancel cancels an in-flight request, reporting whether it was found
+func (s *session) cancel(id interface{}) bool {
+	s.mu.Lock()
+	cancel, ok := s.inflight[requestKey(id)]
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// newEventStreamSession switches an HTTP response to an event stream so
+// notifications can be delivered before the final response
+func newEventStreamSession(w http.ResponseWriter) (*session, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	return newSession(func(message interface{}) error {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}), true
+}
+
+// write sends a message to the client
+func (s *session) write(message interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.send == nil {
+		return fmt.Errorf("session cannot send messages")
+	}
+	return s.send(message)
+}
+
+// close detaches the session from its transport; later messages are dropped
+func (s *session) close() {
+	if s.subscription != nil {
+		s.subscription.Close()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.send = nil
+}
+
+// canSend reports whether the session can deliver server-initiated messages
+func (s *session) canSend() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.send != nil
+}
+
+// notify sends a notification to the client if the transport supports it
+func (s *session) notify(method string, params interface{}) {
+	if !s.canSend() {
+		return
+	}
+
+	notification := &MCPNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}
+	if err := s.write(notification); err != nil {
+		logger.Debug("Failed to send notification",
+			logger.SessionID(s.id),
+			zap.String("method", method),
+			zap.Error(err))
+	}
+}
+
+// progressReporter returns a ProgressFunc that forwards updates as
+// notifications/progress for token, or nil if no progress was requested
+func (s *session) progressReporter(token interface{}) requester.ProgressFunc {
+	if token == nil || !s.canSend() {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var last float64
+
+	return func(progress, total float64, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Progress must increase with every notification
+		if progress <= last {
+			return
+		}
+		last = progress
+
+		params := map[string]interface{}{
+			"progressToken": token,
+			"progress":      progress,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		if message != "" {
+			params["message"] = message
+		}
+
+		s.notify("notifications/progress", params)
+	}
+}
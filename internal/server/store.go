@@ -0,0 +1,185 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// responseResourcePrefix identifies full responses kept as resources
+const responseResourcePrefix = "oas-mcp://responses/"
+
+// responseStore keeps full upstream responses that were too large for a tool
+// result, so clients can read them as resources. Entries expire after a TTL
+// and the oldest are evicted when the store is full.
+type responseStore struct {
+	mu       sync.Mutex
+	entries  map[string]*storedResponse
+	order    []string
+	size     int64
+	maxBytes int64
+	ttl      time.Duration
+}
+
+// storedResponse is one response body kept in the store
+type storedResponse struct {
+	uri      string
+	name     string
+	mimeType string
+	data     []byte
+	text     bool
+	expires  time.Time
+}
+
+// newResponseStore creates a store holding at most maxBytes of responses
+func newResponseStore(maxBytes int64, ttl time.Duration) *responseStore {
+	return &responseStore{
+		entries:  make(map[string]*storedResponse),
+		maxBytes: maxBytes,
+		ttl:      ttl,
+	}
+}
+
+// put stores a response body and returns it, or nil if it does not fit
+func (st *responseStore) put(name, mimeType string, data []byte, text bool) *storedResponse {
+	size := int64(len(data))
+	if size > st.maxBytes {
+		return nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil
+	}
+	entry := &storedResponse{
+		uri:      responseResourcePrefix + hex.EncodeToString(id),
+		name:     name,
+		mimeType: mimeType,
+		data:     data,
+		text:     text,
+		expires:  time.Now().Add(st.ttl),
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.expire()
+	for st.size+size > st.maxBytes && len(st.order) > 0 {
+		st.remove(st.order[0])
+	}
+
+	st.entries[entry.uri] = entry
+	st.order = append(st.order, entry.uri)
+	st.size += size
+
+	return entry
+}
+
+// get returns a stored response that has not expired
+func (st *responseStore) get(uri string) (*storedResponse, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.expire()
+	entry, ok := st.entries[uri]
+	return entry, ok
+}
+
+// expire removes expired entries, which are always the oldest
+func (st *responseStore) expire() {
+	now := time.Now()
+	for len(st.order) > 0 && st.entries[st.order[0]].expires.Before(now) {
+		st.remove(st.order[0])
+	}
+}
+
+// remove deletes an entry
+func (st *responseStore) remove(uri string) {
+	if entry, ok := st.entries[uri]; ok {
+		st.size -= int64(len(entry.data))
+		delete(st.entries, uri)
+	}
+	for i, key := range st.order {
+		if key == uri {
+			st.order = append(st.order[:i], st.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// link returns a resource_link to a stored response
+func (entry *storedResponse) link() resourceLink {
+	return resourceLink{
+		URI:         entry.uri,
+		Name:        entry.name,
+		Description: "Full upstream response; append ?offset=<byte>&length=<bytes> to the uri to read a range",
+		MimeType:    entry.mimeType,
+		Size:        len(entry.data),
+	}
+}
+
+// readStoredResponse returns a stored response, or the byte range selected by
+// the offset and length query parameters of its uri. Text ranges are widened
+// to whole UTF-8 characters.
+func (s *Server) readStoredResponse(uri string) ([]ResourceContents, error) {
+	base, rawQuery, _ := strings.Cut(uri, "?")
+	entry, ok := s.responses.get(base)
+	if !ok {
+		return nil, fmt.Errorf("resource not found or expired: %s", base)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range in resource uri: %s", uri)
+	}
+	start, err := rangeParameter(query, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	length, err := rangeParameter(query, "length", len(entry.data))
+	if err != nil {
+		return nil, err
+	}
+
+	start = min(start, len(entry.data))
+	end := len(entry.data)
+	if length < end-start {
+		end = start + length
+	}
+	if entry.text {
+		for start > 0 && !utf8.RuneStart(entry.data[start]) {
+			start--
+		}
+		for end < len(entry.data) && !utf8.RuneStart(entry.data[end]) {
+			end++
+		}
+	}
+
+	contents := ResourceContents{URI: uri, MimeType: entry.mimeType}
+	if entry.text {
+		contents.Text = string(entry.data[start:end])
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(entry.data[start:end])
+	}
+	return []ResourceContents{contents}, nil
+}
+
+// rangeParameter parses a non-negative integer query parameter
+func rangeParameter(query url.Values, name string, fallback int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s in resource uri: %s", name, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+// uuidPattern matches the textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// argumentViolation is one problem found in the arguments of a tool call,
+// located by a JSON pointer into the arguments object
+type argumentViolation struct {
+	Pointer string
+	Message string
+}
+
+// argumentValidator checks argument values against OpenAPI schemas
+type argumentValidator struct {
+	coerce     bool
+	violations []argumentViolation
+}
+
+// validateArguments checks the arguments of a tool call against its input
+// schema and the operation's parameter and request body schemas. It returns
+// the arguments, with lenient values coerced when enabled, and every violation.
+func (s *Server) validateArguments(tool *Tool, arguments map[string]interface{}) (map[string]interface{}, []argumentViolation) {
+	v := &argumentValidator{coerce: s.config.Tools.CoerceArguments}
+	schemas := s.argumentSchemas(tool.Operation, arguments)
+	validated := make(map[string]interface{}, len(arguments))
+
+	for _, name := range tool.InputSchema.Required {
+		if _, ok := arguments[name]; !ok {
+			v.report(pointerTo("", name), "missing required argument")
+		}
+	}
+
+	for _, name := range sortedKeys(arguments) {
+		value := arguments[name]
+		if _, ok := tool.InputSchema.Properties[name]; !ok {
+			v.report(pointerTo("", name), "unknown argument")
+			continue
+		}
+		validated[name] = v.validate(schemas[name], value, pointerTo("", name))
+	}
+
+	return validated, v.violations
+}
+
+// argumentSchemas maps argument names to the expanded schemas of the
+// operation's parameters and request body
+func (s *Server) argumentSchemas(op *parser.OperationInfo, arguments map[string]interface{}) map[string]*parser.Schema {
+	schemas := make(map[string]*parser.Schema)
+
+	for _, param := range op.Operation.Parameters {
+		schemas[param.Name] = s.parser.ExpandSchema(s.spec, param.EffectiveSchema())
+	}
+
+	content := op.Operation.RequestBodyContent()
+	if len(content) > 1 {
+		enum := make([]interface{}, 0, len(content))
+		for _, mediaType := range sortedKeys(content) {
+			enum = append(enum, mediaType)
+		}
+		schemas[bodyContentTypeArgument] = &parser.Schema{Type: "string", Enum: enum}
+	}
+
+	if hasReservedArguments(op) {
+		for name, schema := range projectionSchemas() {
+			schemas[name] = schema
+		}
+	}
+
+	// The body is validated against the schema of the media type it is sent as
+	if op.Operation.RequestBody != nil {
+		mediaType, err := selectMediaType(op, arguments)
+		if err == nil && mediaType != "" {
+			schemas["body"] = s.parser.ExpandSchema(s.spec, content[mediaType].Schema)
+		}
+	}
+
+	return schemas
+}
+
+// report records a violation
+func (v *argumentValidator) report(pointer, message string) {
+	v.violations = append(v.violations, argumentViolation{Pointer: pointer, Message: message})
+}
+
+// validate checks a value against a schema and returns it, coerced if needed.
+// A nil schema, or a $ref left by recursion, accepts any value.
+func (v *argumentValidator) validate(schema *parser.Schema, value interface{}, pointer string) interface{} {
+	if schema == nil || schema.Ref != "" {
+		return value
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			v.report(pointer, fmt.Sprintf("expected %s, got null", schema.Type))
+		}
+		return value
+	}
+
+	for _, sub := range schema.AllOf {
+		value = v.validate(sub, value, pointer)
+	}
+	if alternatives := append(append([]*parser.Schema{}, schema.OneOf...), schema.AnyOf...); len(alternatives) > 0 {
+		value = v.validateAlternatives(alternatives, value, pointer)
+	}
+
+	// File content may also be given as a {"base64"} or {"path"} object
+	if isFileSchema(schema) && isFileObject(value) {
+		return value
+	}
+
+	if schema.Type != "" {
+		coerced, ok := v.checkType(schema.Type, value)
+		if !ok {
+			v.report(pointer, fmt.Sprintf("expected %s, got %s", schema.Type, jsonTypeName(value)))
+			return value
+		}
+		value = coerced
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		v.report(pointer, fmt.Sprintf("must be one of %s", joinValues(schema.Enum)))
+	}
+
+	if text, ok := value.(string); ok && schema.Format != "" {
+		if message := checkFormat(schema.Format, text); message != "" {
+			v.report(pointer, message)
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := typed[name]; !ok {
+				v.report(pointerTo(pointer, name), "missing required property")
+			}
+		}
+		if len(schema.Properties) > 0 {
+			object := make(map[string]interface{}, len(typed))
+			for _, name := range sortedKeys(typed) {
+				object[name] = v.validate(schema.Properties[name], typed[name], pointerTo(pointer, name))
+			}
+			value = object
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			array := make([]interface{}, len(typed))
+			for i, item := range typed {
+				array[i] = v.validate(schema.Items, item, pointerTo(pointer, strconv.Itoa(i)))
+			}
+			value = array
+		}
+	}
+
+	return value
+}
+
+// validateAlternatives accepts a value matching any of the schemas,
+// reporting a single violation when none matches
+func (v *argumentValidator) validateAlternatives(schemas []*parser.Schema, value interface{}, pointer string) interface{} {
+	for _, schema := range schemas {
+		alternative := &argumentValidator{coerce: v.coerce}
+		coerced := alternative.validate(schema, value, pointer)
+		if len(alternative.violations) == 0 {
+			return coerced
+		}
+	}
+
+	v.report(pointer, "does not match any of the allowed schemas")
+	return value
+}
+
+// checkType reports whether a decoded JSON value has a schema type,
+// coercing numeric and boolean strings, and numbers and booleans given for
+// strings, when enabled
+func (v *argumentValidator) checkType(schemaType string, value interface{}) (interface{}, bool) {
+	switch schemaType {
+	case "string":
+		switch value.(type) {
+		case string:
+			return value, true
+		case float64, int, int64, bool:
+			if v.coerce {
+				return formatScalar(value), true
+			}
+		}
+		return value, false
+	case "integer":
+		if number, ok := v.number(value); ok && number == math.Trunc(number) {
+			return number, true
+		}
+		return value, false
+	case "number":
+		if number, ok := v.number(value); ok {
+			return number, true
+		}
+		return value, false
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return b, true
+		}
+		if text, ok := value.(string); ok && v.coerce && (text == "true" || text == "false") {
+			return text == "true", true
+		}
+		return value, false
+	case "array":
+		_, ok := value.([]interface{})
+		return value, ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return value, ok
+	}
+	return value, true
+}
+
+// number returns a numeric value, parsing numeric strings when coercing
+func (v *argumentValidator) number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		if !v.coerce {
+			return 0, false
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// checkFormat validates the well-known string formats, returning a message
+// for invalid values. Unknown formats are accepted.
+func checkFormat(format, value string) string {
+	var valid bool
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		valid = err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		valid = err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		valid = err == nil
+	case "uri", "url":
+		u, err := url.Parse(value)
+		valid = err == nil && u.Scheme != ""
+	case "uuid":
+		valid = uuidPattern.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		valid = ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(value)
+		valid = ip != nil && ip.To4() == nil
+	default:
+		return ""
+	}
+
+	if valid {
+		return ""
+	}
+	return fmt.Sprintf("%q is not a valid %s", value, format)
+}
+
+// enumContains reports whether value equals one of the enum values
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprintf("%v", allowed) == fmt.Sprintf("%v", value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeName names the JSON type of a decoded value
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// pointerTo appends a reference token to a JSON pointer
+func pointerTo(pointer, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return pointer + "/" + token
+}
+
+// invalidArgumentsResult reports all argument violations as one tool error
+func invalidArgumentsResult(name string, violations []argumentViolation, hint string) *toolResult {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Invalid arguments for %s:\n", name)
+	for _, violation := range violations {
+		fmt.Fprintf(&b, "- %s: %s\n", violation.Pointer, violation.Message)
+	}
+	b.WriteString(hint)
+
+	return &toolResult{
+		Text:    b.String(),
+		IsError: true,
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/feitianbubu/oas-mcp/internal/parser"
+)
+
+func TestCheckTypeCoercion(t *testing.T) {
+	tests := []struct {
+		name       string
+		schemaType string
+		value      interface{}
+		coerce     bool
+		want       interface{}
+		wantOK     bool
+	}{
+		{name: "string", schemaType: "string", value: "abc", want: "abc", wantOK: true},
+		{name: "number for string", schemaType: "string", value: 123.0, wantOK: false},
+		{name: "number to string", schemaType: "string", value: 123.0, coerce: true, want: "123", wantOK: true},
+		{name: "fraction to string", schemaType: "string", value: 1.5, coerce: true, want: "1.5", wantOK: true},
+		{name: "boolean for string", schemaType: "string", value: true, wantOK: false},
+		{name: "boolean to string", schemaType: "string", value: true, coerce: true, want: "true", wantOK: true},
+		{name: "object is never a string", schemaType: "string", value: map[string]interface{}{}, coerce: true, wantOK: false},
+		{name: "array is never a string", schemaType: "string", value: []interface{}{"a"}, coerce: true, wantOK: false},
+		{name: "integer", schemaType: "integer", value: 42.0, want: 42.0, wantOK: true},
+		{name: "fraction for integer", schemaType: "integer", value: 4.2, wantOK: false},
+		{name: "string for integer", schemaType: "integer", value: "42", wantOK: false},
+		{name: "string to integer", schemaType: "integer", value: " 42 ", coerce: true, want: 42.0, wantOK: true},
+		{name: "fraction string to integer", schemaType: "integer", value: "4.2", coerce: true, wantOK: false},
+		{name: "string for number", schemaType: "number", value: "3.5", wantOK: false},
+		{name: "string to number", schemaType: "number", value: "3.5", coerce: true, want: 3.5, wantOK: true},
+		{name: "text to number", schemaType: "number", value: "three", coerce: true, wantOK: false},
+		{name: "string for boolean", schemaType: "boolean", value: "true", wantOK: false},
+		{name: "string to boolean", schemaType: "boolean", value: "false", coerce: true, want: false, wantOK: true},
+		{name: "other string to boolean", schemaType: "boolean", value: "yes", coerce: true, wantOK: false},
+		{name: "number to boolean", schemaType: "boolean", value: 1.0, coerce: true, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &argumentValidator{coerce: tt.coerce}
+			got, ok := v.checkType(tt.schemaType, tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("checkType(%q, %#v) ok = %v, want %v", tt.schemaType, tt.value, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkType(%q, %#v) = %#v, want %#v", tt.schemaType, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCoercesNestedValues(t *testing.T) {
+	schema := &parser.Schema{
+		Type: "object",
+		Properties: map[string]*parser.Schema{
+			"id":     {Type: "string"},
+			"count":  {Type: "integer"},
+			"active": {Type: "boolean"},
+			"tags":   {Type: "array", Items: &parser.Schema{Type: "string"}},
+		},
+	}
+	value := map[string]interface{}{
+		"id":     123.0,
+		"count":  "7",
+		"active": "true",
+		"tags":   []interface{}{1.0, false},
+	}
+	want := map[string]interface{}{
+		"id":     "123",
+		"count":  7.0,
+		"active": true,
+		"tags":   []interface{}{"1", "false"},
+	}
+
+	v := &argumentValidator{coerce: true}
+	got := v.validate(schema, value, "")
+	if len(v.violations) > 0 {
+		t.Fatalf("unexpected violations: %v", v.violations)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validate() = %#v, want %#v", got, want)
+	}
+
+	strict := &argumentValidator{}
+	strict.validate(schema, value, "")
+	if len(strict.violations) != 5 {
+		t.Errorf("got %d violations without coercion, want 5: %v", len(strict.violations), strict.violations)
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *parser.Schema
+		value  interface{}
+		want   []argumentViolation
+	}{
+		{name: "no schema", value: map[string]interface{}{"a": 1.0}},
+		{name: "unresolved ref", schema: &parser.Schema{Ref: "#/components/schemas/Node"}, value: "x"},
+		{name: "type mismatch", schema: &parser.Schema{Type: "integer"}, value: "x", want: []argumentViolation{{"", "expected integer, got string"}}},
+		{name: "null", schema: &parser.Schema{Type: "string"}, value: nil, want: []argumentViolation{{"", "expected string, got null"}}},
+		{name: "nullable", schema: &parser.Schema{Type: "string", Nullable: true}, value: nil},
+		{name: "enum", schema: &parser.Schema{Type: "string", Enum: []interface{}{"a", "b"}}, value: "c", want: []argumentViolation{{"", "must be one of `a`, `b`"}}},
+		{name: "numeric enum", schema: &parser.Schema{Type: "integer", Enum: []interface{}{1, 2}}, value: 2.0},
+		{name: "format", schema: &parser.Schema{Type: "string", Format: "date"}, value: "2024-13-01", want: []argumentViolation{{"", `"2024-13-01" is not a valid date`}}},
+		{name: "unknown format", schema: &parser.Schema{Type: "string", Format: "color"}, value: "red"},
+		{
+			name: "required property",
+			schema: &parser.Schema{
+				Type:       "object",
+				Required:   []string{"id", "a/b"},
+				Properties: map[string]*parser.Schema{"id": {Type: "string"}},
+			},
+			value: map[string]interface{}{"id": "x"},
+			want:  []argumentViolation{{"/a~1b", "missing required property"}},
+		},
+		{
+			name:   "array items",
+			schema: &parser.Schema{Type: "array", Items: &parser.Schema{Type: "integer"}},
+			value:  []interface{}{1.0, "two", 3.0},
+			want:   []argumentViolation{{"/1", "expected integer, got string"}},
+		},
+		{
+			name:   "allOf",
+			schema: &parser.Schema{AllOf: []*parser.Schema{{Type: "object", Required: []string{"a"}}, {Type: "object", Required: []string{"b"}}}},
+			value:  map[string]interface{}{"a": 1.0},
+			want:   []argumentViolation{{"/b", "missing required property"}},
+		},
+		{
+			name:   "oneOf match",
+			schema: &parser.Schema{OneOf: []*parser.Schema{{Type: "integer"}, {Type: "string", Format: "uuid"}}},
+			value:  "123e4567-e89b-12d3-a456-426614174000",
+		},
+		{
+			name:   "oneOf mismatch",
+			schema: &parser.Schema{OneOf: []*parser.Schema{{Type: "integer"}, {Type: "string", Format: "uuid"}}},
+			value:  "abc",
+			want:   []argumentViolation{{"", "does not match any of the allowed schemas"}},
+		},
+		{
+			name:   "anyOf",
+			schema: &parser.Schema{AnyOf: []*parser.Schema{{Type: "boolean"}, {Type: "array"}}},
+			value:  1.0,
+			want:   []argumentViolation{{"", "does not match any of the allowed schemas"}},
+		},
+		{
+			name:   "file object for binary",
+			schema: &parser.Schema{Type: "string", Format: "binary"},
+			value:  map[string]interface{}{"path": "/tmp/file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &argumentValidator{}
+			v.validate(tt.schema, tt.value, "")
+			if !reflect.DeepEqual(v.violations, tt.want) {
+				t.Errorf("violations = %v, want %v", v.violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{"date", "2024-02-29", true},
+		{"date", "2024-02-30", false},
+		{"date-time", "2024-02-29T12:00:00Z", true},
+		{"date-time", "2024-02-29 12:00", false},
+		{"email", "a@example.com", true},
+		{"email", "example.com", false},
+		{"uri", "https://example.com/a", true},
+		{"uri", "/relative", false},
+		{"uuid", "123E4567-E89B-12D3-A456-426614174000", true},
+		{"uuid", "123e4567e89b12d3a456426614174000", false},
+		{"ipv4", "192.0.2.1", true},
+		{"ipv4", "2001:db8::1", false},
+		{"ipv6", "2001:db8::1", true},
+		{"ipv6", "192.0.2.1", false},
+		{"hostname", "not checked", true},
+	}
+
+	for _, tt := range tests {
+		if message := checkFormat(tt.format, tt.value); (message == "") != tt.valid {
+			t.Errorf("checkFormat(%q, %q) = %q, want valid %v", tt.format, tt.value, message, tt.valid)
+		}
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets/{id}:
+    put:
+      operationId: updatePet
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+        - {name: dryRun, in: query, schema: {type: boolean}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                status: {type: string, enum: [available, sold]}
+          text/plain:
+            schema: {type: string}
+      responses:
+        "204": {description: Updated}
+`
+
+	tests := []struct {
+		name      string
+		coerce    bool
+		arguments map[string]interface{}
+		want      map[string]interface{}
+		wantErrs  []argumentViolation
+	}{
+		{
+			name:      "valid",
+			arguments: map[string]interface{}{"id": 1.0, "body": map[string]interface{}{"name": "Rex"}},
+			want:      map[string]interface{}{"id": 1.0, "body": map[string]interface{}{"name": "Rex"}},
+		},
+		{
+			name:      "missing and unknown arguments",
+			arguments: map[string]interface{}{"petId": 1.0},
+			wantErrs: []argumentViolation{
+				{"/id", "missing required argument"},
+				{"/body", "missing required argument"},
+				{"/petId", "unknown argument"},
+			},
+		},
+		{
+			name:      "every violation is reported",
+			arguments: map[string]interface{}{"id": "1", "dryRun": "yes", "body": map[string]interface{}{"status": "lost"}},
+			wantErrs: []argumentViolation{
+				{"/body/name", "missing required property"},
+				{"/body/status", "must be one of `available`, `sold`"},
+				{"/dryRun", "expected boolean, got string"},
+				{"/id", "expected integer, got string"},
+			},
+		},
+		{
+			name:      "coerced",
+			coerce:    true,
+			arguments: map[string]interface{}{"id": "7", "dryRun": "true", "body": map[string]interface{}{"name": 42.0}},
+			want:      map[string]interface{}{"id": 7.0, "dryRun": true, "body": map[string]interface{}{"name": "42"}},
+		},
+		{
+			name:      "body follows the selected media type",
+			arguments: map[string]interface{}{"id": 1.0, bodyContentTypeArgument: "text/plain", "body": map[string]interface{}{"name": "Rex"}},
+			wantErrs:  []argumentViolation{{"/body", "expected string, got object"}},
+		},
+		{
+			name:      "unknown media type",
+			arguments: map[string]interface{}{"id": 1.0, bodyContentTypeArgument: "text/csv", "body": "Rex"},
+			wantErrs:  []argumentViolation{{"/" + bodyContentTypeArgument, "must be one of `application/json`, `text/plain`"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig("http://localhost")
+			cfg.Tools.CoerceArguments = tt.coerce
+			s := newTestServer(t, spec, cfg)
+			tool := s.catalog().tool("updatePet")
+			if tool == nil {
+				t.Fatal("updatePet tool not found")
+			}
+
+			got, violations := s.validateArguments(tool, tt.arguments)
+			if !reflect.DeepEqual(violations, tt.wantErrs) {
+				t.Fatalf("violations = %v, want %v", violations, tt.wantErrs)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("arguments = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
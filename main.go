@@ -59,6 +59,15 @@ func main() {
 		}
 	}()
 
+	// Authorize with the OAuth2 authorization code flow and exit
+	if pflag.Arg(0) == "login" {
+		if err := login(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Create app with dependencies
 	app := fx.New(
 		fx.NopLogger,
@@ -96,3 +105,12 @@ func main() {
 
 	app.Run()
 }
+
+// login runs the OAuth2 login flow against the security schemes of the spec
+func login(cfg *config.Config) error {
+	spec, err := parser.NewParser().ParseFile(cfg.SwaggerFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	return requester.Login(context.Background(), cfg, spec.SecuritySchemes(), os.Stderr)
+}